@@ -0,0 +1,191 @@
+// Gotmpl renders Go templates from the command line, with the same engine as the
+// playground, so that its output is byte-identical to what the playground shows.
+//
+// Usage:
+//
+//	gotmpl [flags] template...
+//	gotmpl -to format [flags]
+//	gotmpl serve [flags]
+//
+// The first form parses the template files into a single set, named after their base
+// names, and executes the entry template with the data files as context. The second
+// form converts the data files to another format instead.
+//
+// Data files may be given in any supported format, which is inferred from their
+// extension unless -format is set. If several data files are given, their maps are
+// merged, with later files taking precedence. A data file named "-" is read from
+// standard input.
+//
+// With -outdir, each template file whose name does not start with an underscore is
+// executed in turn and written to a file in the directory, named after the template
+// with a ".tmpl", ".gotmpl" or ".tpl" extension removed.
+//
+// The third form serves the render, transform, analyze and check endpoints of package
+// server over HTTP, until interrupted.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := serve(ctx, os.Args[2:], os.Stderr)
+		stop()
+		os.Exit(code)
+	}
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// config holds the parsed command line.
+type config struct {
+	templates []string
+	data      []string
+	format    codec.Format
+	to        codec.Format
+	output    string
+	outdir    string
+	encoder   codec.EncoderOptions
+	options   tmpl.Options
+}
+
+// stringsFlag is a flag that may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// parseFlags parses the command line arguments into a config.
+func parseFlags(args []string, stderr io.Writer) (*config, error) {
+	var (
+		cfg  config
+		data stringsFlag
+		fs   = flag.NewFlagSet("gotmpl", flag.ContinueOnError)
+
+		format, to                    string
+		indent                        int
+		insertSpaces, noIndent        bool
+		engine, delims, missingKey    string
+		timeout                       time.Duration
+		maxOutput, maxSteps, maxDepth int
+		now, timeZone                 string
+		seed                          uint64
+		deterministic                 bool
+	)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  gotmpl [flags] template...\n  gotmpl -to format [flags]\n  gotmpl serve [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Var(&data, "data", "data `file`; may be repeated, and \"-\" reads standard input")
+	fs.StringVar(&format, "format", "", "`format` of the data files (json, yaml, toml, xml, csv or tsv); inferred from their extension by default")
+	fs.StringVar(&to, "to", "", "convert the data to `format` instead of rendering templates")
+	fs.StringVar(&cfg.output, "o", "", "write the output to `file` instead of standard output")
+	fs.StringVar(&cfg.outdir, "outdir", "", "render each template file into `dir`")
+
+	fs.IntVar(&indent, "indent", 0, "number of spaces or tabs per indent when converting data")
+	fs.BoolVar(&insertSpaces, "spaces", false, "indent converted data with spaces instead of tabs")
+	fs.BoolVar(&noIndent, "no-indent", false, "do not indent converted data")
+
+	fs.StringVar(&cfg.options.Entry, "entry", "", "`name` of the template to execute")
+	fs.StringVar(&engine, "engine", "", "template `engine`, text or html (default text)")
+	fs.StringVar(&delims, "delims", "", "left and right action `delimiters`, separated by a space (default \"{{ }}\")")
+	fs.StringVar(&missingKey, "missingkey", "", "behavior when a map has no entry for a key: default, zero or error")
+
+	fs.DurationVar(&timeout, "timeout", 0, "execution deadline; negative to disable (default 5s)")
+	fs.IntVar(&maxOutput, "max-output", 0, "maximum output size in bytes; negative to disable (default 8 MiB)")
+	fs.IntVar(&maxSteps, "max-steps", 0, "maximum number of range iterations and template calls; negative to disable (default 1000000)")
+	fs.IntVar(&maxDepth, "max-depth", 0, "maximum nesting depth of template calls; negative to disable (default 256)")
+
+	fs.StringVar(&now, "now", "", "fix the `instant` returned by now and date, in RFC 3339 format")
+	fs.StringVar(&timeZone, "tz", "", "IANA time `zone` of now and date (default local)")
+	fs.Uint64Var(&seed, "seed", 0, "seed of the random source used by randInt; makes output deterministic")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		deterministic = deterministic || f.Name == "now" || f.Name == "tz" || f.Name == "seed"
+	})
+
+	cfg.templates, cfg.data = fs.Args(), data
+	cfg.format, cfg.to = codec.Format(format), codec.Format(to)
+	cfg.encoder = codec.EncoderOptions{InsertSpaces: insertSpaces, IndentSize: indent, NoIndent: noIndent}
+	cfg.options.Engine, cfg.options.MissingKey = tmpl.Engine(engine), tmpl.MissingKey(missingKey)
+	cfg.options.Limits = tmpl.Limits{Timeout: timeout, MaxOutputBytes: maxOutput, MaxSteps: maxSteps, MaxDepth: maxDepth}
+	if delims != "" {
+		left, right, ok := strings.Cut(delims, " ")
+		if !ok || left == "" || right == "" {
+			return nil, fmt.Errorf("invalid -delims %q: expected two non-empty delimiters separated by a space", delims)
+		}
+		cfg.options.LeftDelim, cfg.options.RightDelim = left, right
+	}
+	if deterministic {
+		d, err := parseDeterminism(now, timeZone, seed)
+		if err != nil {
+			return nil, err
+		}
+		cfg.options.Deterministic = d
+	}
+
+	switch {
+	case cfg.to != "" && len(cfg.templates) > 0:
+		return nil, errors.New("-to converts data and takes no templates")
+	case cfg.to == "" && len(cfg.templates) == 0:
+		return nil, errors.New("no templates given")
+	case cfg.output != "" && cfg.outdir != "":
+		return nil, errors.New("-o and -outdir are mutually exclusive")
+	}
+	return &cfg, nil
+}
+
+func parseDeterminism(now, timeZone string, seed uint64) (*tmpl.Determinism, error) {
+	d := &tmpl.Determinism{Seed: seed}
+	var err error
+	if now != "" {
+		if d.Now, err = time.Parse(time.RFC3339Nano, now); err != nil {
+			return nil, fmt.Errorf("invalid -now: %w", err)
+		}
+	}
+	if timeZone != "" {
+		if d.Location, err = time.LoadLocation(timeZone); err != nil {
+			return nil, fmt.Errorf("invalid -tz: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// run executes the command with the given arguments and returns its exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseFlags(args, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(stderr, "gotmpl:", err)
+		return 2
+	}
+	if err := cfg.run(stdin, stdout); err != nil {
+		printError(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes files to a temporary directory and returns its path.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func Test_run(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"values.yaml":   "name: World\nimage:\n  tag: v1\n  repo: app\n",
+		"override.json": `{"image": {"tag": "v2"}}`,
+		"index.tmpl":    `{{template "_header.tmpl" .}} {{.image.repo}}:{{.image.tag}}`,
+		"_header.tmpl":  `Hello, {{.name}}!`,
+		"clock.tmpl":    `{{now.Format "2006-01-02 15:04 MST"}} {{date}}`,
+		"delims.tmpl":   `{{ .name }} [[.name]]`,
+		"missing.tmpl":  "ok\n{{.nope}}",
+		"loop.tmpl":     `{{range 1000}}{{end}}`,
+		"bad.json":      "{\n  \"a\": 1,\n}",
+		"data.txt":      `{}`,
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "Render",
+			args:       []string{"-data", path("values.yaml"), "-entry", "index.tmpl", path("index.tmpl"), path("_header.tmpl")},
+			wantStdout: "Hello, World! app:v1",
+		},
+		{
+			name: "MergedData",
+			args: []string{
+				"-data", path("values.yaml"), "-data", path("override.json"),
+				"-entry", "index.tmpl", path("index.tmpl"), path("_header.tmpl"),
+			},
+			wantStdout: "Hello, World! app:v2",
+		},
+		{
+			name:       "Stdin",
+			args:       []string{"-data", "-", "-format", "toml", path("_header.tmpl")},
+			stdin:      `name = "TOML"`,
+			wantStdout: "Hello, TOML!",
+		},
+		{
+			name:       "Deterministic",
+			args:       []string{"-now", "2024-06-30T22:15:00Z", "-tz", "Asia/Tokyo", path("clock.tmpl")},
+			wantStdout: "2024-07-01 07:15 JST 2024-07-01",
+		},
+		{
+			name:       "Delims",
+			args:       []string{"-data", path("values.yaml"), "-delims", "[[ ]]", path("delims.tmpl")},
+			wantStdout: "{{ .name }} World",
+		},
+		{
+			name:       "Convert",
+			args:       []string{"-data", path("values.yaml"), "-to", "json", "-spaces", "-indent", "2"},
+			wantStdout: "{\n  \"name\": \"World\",\n  \"image\": {\n    \"tag\": \"v1\",\n    \"repo\": \"app\"\n  }\n}\n",
+		},
+		{
+			name:       "MissingKeyError",
+			args:       []string{"-data", path("values.yaml"), "-missingkey", "error", path("missing.tmpl")},
+			wantCode:   1,
+			wantStderr: path("missing.tmpl") + `:2:3: execute error: executing "missing.tmpl" at <.nope>: map has no entry for key "nope"`,
+		},
+		{
+			name:       "LimitError",
+			args:       []string{"-max-steps", "10", path("loop.tmpl")},
+			wantCode:   1,
+			wantStderr: path("loop.tmpl") + ":1:9: execute error: limit exceeded: execution took more than 10 steps (ErrStepLimit)",
+		},
+		{
+			name:       "DecodeError",
+			args:       []string{"-data", path("bad.json"), path("_header.tmpl")},
+			wantCode:   1,
+			wantStderr: path("bad.json") + ":3:1: decode error: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:       "UnknownFormat",
+			args:       []string{"-data", path("data.txt"), path("_header.tmpl")},
+			wantCode:   1,
+			wantStderr: "gotmpl: cannot infer the format of " + path("data.txt") + "; use -format",
+		},
+		{
+			name:       "NoTemplates",
+			args:       []string{"-data", path("values.yaml")},
+			wantCode:   2,
+			wantStderr: "gotmpl: no templates given",
+		},
+		{
+			name:       "ConvertWithTemplates",
+			args:       []string{"-to", "json", path("index.tmpl")},
+			wantCode:   2,
+			wantStderr: "gotmpl: -to converts data and takes no templates",
+		},
+		{
+			name:       "InvalidDelims",
+			args:       []string{"-delims", "[[", path("index.tmpl")},
+			wantCode:   2,
+			wantStderr: `gotmpl: invalid -delims "[[": expected two non-empty delimiters separated by a space`,
+		},
+		{
+			name:       "EmptyLeftDelim",
+			args:       []string{"-delims", " ]]", path("index.tmpl")},
+			wantCode:   2,
+			wantStderr: `gotmpl: invalid -delims " ]]": expected two non-empty delimiters separated by a space`,
+		},
+		{
+			name:       "EmptyRightDelim",
+			args:       []string{"-delims", "[[ ", path("index.tmpl")},
+			wantCode:   2,
+			wantStderr: `gotmpl: invalid -delims "[[ ": expected two non-empty delimiters separated by a space`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code, stderr.String())
+			assert.Equal(t, tt.wantStdout, stdout.String())
+			assert.Equal(t, tt.wantStderr, strings.TrimSpace(stderr.String()))
+		})
+	}
+}
+
+func Test_run_outdir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"values.json":         `{"name": "app"}`,
+		"_helpers.tmpl":       `{{define "label"}}app={{.name}}{{end}}`,
+		"deployment.yaml.tpl": `label: {{template "label" .}}`,
+		"page.html":           `<p>{{.name}}</p>`,
+	})
+	outdir := filepath.Join(t.TempDir(), "out")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{
+		"-data", filepath.Join(dir, "values.json"), "-engine", "html", "-outdir", outdir,
+		filepath.Join(dir, "_helpers.tmpl"), filepath.Join(dir, "deployment.yaml.tpl"), filepath.Join(dir, "page.html"),
+	}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Empty(t, stdout.String())
+
+	entries, err := os.ReadDir(outdir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	got, err := os.ReadFile(filepath.Join(outdir, "deployment.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "label: app=app", string(got))
+	got, err = os.ReadFile(filepath.Join(outdir, "page.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>app</p>", string(got))
+}
+
+func Test_merge(t *testing.T) {
+	decode := func(s string) any {
+		v, err := engine.Decode([]byte(s), codec.FormatJSON, nil)
+		require.NoError(t, err)
+		return v
+	}
+	got := merge(
+		decode(`{"a": {"c": 2, "b": 1}, "d": [1]}`),
+		decode(`{"e": 0, "a": {"c": 3}, "d": [2]}`),
+	)
+	out, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":{"c":3,"b":1},"d":[2],"e":0}`, string(out))
+	assert.Equal(t, "x", merge(codec.NewMap(0), "x"))
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// templateExts are the extensions removed from template names to name output files.
+var templateExts = []string{".tmpl", ".gotmpl", ".tpl"}
+
+// sourceError is an error in one of the files given on the command line.
+type sourceError struct {
+	err     error
+	sources diagnostic.Sources
+	paths   map[string]string // Paths of the templates in sources, by name.
+	data    string            // Path of the data file in sources.
+}
+
+func (e *sourceError) Error() string { return e.err.Error() }
+
+func (e *sourceError) Unwrap() error { return e.err }
+
+func (c *config) run(stdin io.Reader, stdout io.Writer) error {
+	data, err := c.loadData(stdin)
+	if err != nil {
+		return err
+	}
+	if c.to != "" {
+		if len(c.data) == 0 {
+			return errors.New("no data given to convert")
+		}
+		out, err := engine.Encode(data, c.to, &c.encoder)
+		if err != nil {
+			return err
+		}
+		return c.write(stdout, c.output, out)
+	}
+
+	files, paths, err := loadTemplates(c.templates)
+	if err != nil {
+		return err
+	}
+	data = codec.Plain(data)
+	e := engine.New(1)
+	if c.outdir == "" {
+		out, err := e.Execute(files, data, c.options)
+		if err != nil {
+			return &sourceError{err: err, sources: diagnostic.Sources{Templates: files}, paths: paths}
+		}
+		return c.write(stdout, c.output, out)
+	}
+
+	if err := os.MkdirAll(c.outdir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range files.Names() {
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		options := c.options
+		options.Entry = name
+		out, err := e.Execute(files, data, options)
+		if err != nil {
+			return &sourceError{err: err, sources: diagnostic.Sources{Templates: files}, paths: paths}
+		}
+		if err := c.write(stdout, filepath.Join(c.outdir, outputName(name)), out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write writes out to the file at path, or to stdout if path is empty.
+func (c *config) write(stdout io.Writer, path string, out []byte) error {
+	if path == "" {
+		_, err := stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(path, out, 0o644)
+}
+
+// outputName returns the name of the file rendered from the template name.
+func outputName(name string) string {
+	for _, ext := range templateExts {
+		if trimmed, ok := strings.CutSuffix(name, ext); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// loadTemplates reads the template files at paths, naming each after its base name.
+func loadTemplates(paths []string) (tmpl.Files, map[string]string, error) {
+	files := make(tmpl.Files, len(paths))
+	byName := make(map[string]string, len(paths))
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if other, ok := byName[name]; ok {
+			return nil, nil, fmt.Errorf("templates %s and %s have the same name %q", other, path, name)
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		files[name], byName[name] = src, path
+	}
+	return files, byName, nil
+}
+
+// loadData decodes and merges the data files, keeping the order of their keys. It
+// returns nil if there are none.
+func (c *config) loadData(stdin io.Reader) (any, error) {
+	var data any
+	for _, path := range c.data {
+		format, err := formatOf(path, c.format)
+		if err != nil {
+			return nil, err
+		}
+		var src []byte
+		if path == "-" {
+			src, err = io.ReadAll(stdin)
+		} else {
+			src, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := engine.Decode(src, format, nil)
+		if err != nil {
+			return nil, &sourceError{err: err, sources: diagnostic.Sources{Data: src}, data: path}
+		}
+		data = merge(data, v)
+	}
+	return data, nil
+}
+
+// formatOf returns format, or if it is empty, the format implied by the extension of path.
+func formatOf(path string, format codec.Format) (codec.Format, error) {
+	if format != "" {
+		return format, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return codec.FormatJSON, nil
+	case ".yaml", ".yml":
+		return codec.FormatYAML, nil
+	case ".toml":
+		return codec.FormatTOML, nil
+	case ".xml":
+		return codec.FormatXML, nil
+	case ".csv":
+		return codec.FormatCSV, nil
+	case ".tsv":
+		return codec.FormatTSV, nil
+	}
+	return "", fmt.Errorf("cannot infer the format of %s; use -format", path)
+}
+
+// merge returns src merged into dst. Maps are merged key by key, with the keys of dst
+// first, and any other value in src replaces the one in dst.
+func merge(dst, src any) any {
+	dstMap, ok1 := dst.(*codec.Map)
+	srcMap, ok2 := src.(*codec.Map)
+	if !ok1 || !ok2 {
+		return src
+	}
+	merged := codec.NewMap(dstMap.Len())
+	for k, v := range dstMap.All() {
+		merged.Set(k, v)
+	}
+	for k, v := range srcMap.All() {
+		prev, _ := merged.Get(k)
+		merged.Set(k, merge(prev, v))
+	}
+	return merged
+}
+
+// printError writes err to w, as one line per diagnostic for errors in source files.
+func printError(w io.Writer, err error) {
+	var srcErr *sourceError
+	if !errors.As(err, &srcErr) {
+		fmt.Fprintln(w, "gotmpl:", err)
+		return
+	}
+	for _, d := range diagnostic.FromError(srcErr.err, srcErr.sources) {
+		loc := cmp.Or(srcErr.data, "gotmpl")
+		if d.Template != "" {
+			loc = cmp.Or(srcErr.paths[d.Template], d.Template)
+		}
+		if d.Line > 0 {
+			loc += fmt.Sprintf(":%d", d.Line)
+		}
+		if d.Column > 0 {
+			loc += fmt.Sprintf(":%d", d.Column)
+		}
+		msg := d.Message
+		if d.Code != "" {
+			msg += " (" + d.Code + ")"
+		}
+		fmt.Fprintf(w, "%s: %s error: %s\n", loc, d.Stage, msg)
+	}
+}
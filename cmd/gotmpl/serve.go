@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/server"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// serve runs the HTTP server until ctx is done, and returns its exit code.
+func serve(ctx context.Context, args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gotmpl serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:\n  gotmpl serve [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "localhost:8080", "`address` to listen on")
+	maxBody := fs.Int64("max-body", server.DefaultMaxBodyBytes, "maximum size of a request body in `bytes`")
+	cacheSize := fs.Int("cache", tmpl.DefaultCacheSize, "number of parsed template sets to cache")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(stderr, "gotmpl:", err)
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "gotmpl: serve takes no arguments")
+		return 2
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(stderr, "gotmpl:", err)
+		return 1
+	}
+	srv := &http.Server{
+		Handler:           server.New(engine.New(*cacheSize), server.Options{MaxBodyBytes: *maxBody}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(stderr, "gotmpl: serving on http://%s\n", ln.Addr())
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = srv.Shutdown(shutdownCtx)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(stderr, "gotmpl:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_serve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var stderr syncBuffer
+	done := make(chan int)
+	go func() { done <- serve(ctx, []string{"-addr", "127.0.0.1:0"}, &stderr) }()
+
+	var addr string
+	require.Eventually(t, func() bool {
+		m := regexp.MustCompile(`serving on (http://\S+)`).FindStringSubmatch(stderr.String())
+		if m != nil {
+			addr = m[1]
+		}
+		return m != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	resp, err := http.Post(addr+"/render", "application/json",
+		strings.NewReader(`{"template": "{{.}}", "data": "1", "format": "json"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	assert.Equal(t, 0, <-done)
+}
+
+func Test_serve_flags(t *testing.T) {
+	var stderr bytes.Buffer
+	assert.Equal(t, 2, serve(context.Background(), []string{"extra"}, &stderr))
+	assert.Equal(t, "gotmpl: serve takes no arguments", strings.TrimSpace(stderr.String()))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	stderr.Reset()
+	assert.Equal(t, 1, serve(context.Background(), []string{"-addr", ln.Addr().String()}, &stderr))
+}
+
+// syncBuffer is a buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
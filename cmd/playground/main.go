@@ -3,13 +3,24 @@
 
 // Playground is a WebAssembly module that provides functions for processing Go templates.
 //
+// The functions are set on the global object, unless the PLAYGROUND_NAMESPACE
+// environment variable names a namespace object to set them on. Hosts set it with the
+// env property of the Go instance of wasm_exec.js:
+//
+//	const go = new Go();
+//	go.env = { PLAYGROUND_NAMESPACE: "gotmpl" };
+//	globalThis.gotmpl = { onReady: (api) => console.log("ready", api) };
+//	go.run(instance); // Resolves once gotmpl.shutdown() is called.
+//
 // See [playground] for information.
 package main
 
 import (
+	"os"
+
 	"github.com/bartventer/go-template-playground/internal/playground"
 )
 
 func main() {
-	playground.InitModule()
+	playground.InitModule(playground.WithNamespace(os.Getenv("PLAYGROUND_NAMESPACE")))
 }
@@ -0,0 +1,26 @@
+//go:build wasip1
+
+// Wasi is a WASI (wasip1) build of the playground engine, for WebAssembly runtimes
+// outside the browser, such as wasmtime, wazero or the node:wasi module of Node.js.
+//
+// It reads a single JSON request from standard input, calls its action and writes the
+// JSON response to standard output, as described in package protocol:
+//
+//	{"action": "ProcessTemplate", "template": "Hello, {{.name}}!", "data": "{\"name\": \"World\"}", "format": "json"}
+//	{"action": "TransformData", "data": "name: World", "prevFormat": "yaml", "nextFormat": "toml"}
+//
+// It exits with status 1 if the response reports an error.
+package main
+
+import (
+	"os"
+
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/protocol"
+)
+
+func main() {
+	if err := protocol.ServeJSON(engine.New(1), os.Stdin, os.Stdout); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,170 @@
+// Package analysis inspects the parse trees of templates without executing them.
+package analysis
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"text/template/parse"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// Reference is a named element of a template and its position in the source.
+type Reference struct {
+	Name     string `json:"name"`     // Name as written, such as ".User.Name", "$x", "upper" or "header.tmpl".
+	Template string `json:"template"` // Name of the template source.
+	Line     int    `json:"line"`     // 1-based line.
+	Column   int    `json:"column"`   // 1-based column.
+	Offset   int    `json:"offset"`   // 0-based byte offset.
+}
+
+// Report lists the elements referenced by a set of templates, ordered by template
+// source name and then by position.
+type Report struct {
+	Fields    []Reference `json:"fields"`    // Field chains, such as ".User.Name".
+	Variables []Reference `json:"variables"` // Variables, such as "$" or "$user.Name".
+	Functions []Reference `json:"functions"` // Function calls, including builtins.
+	Templates []Reference `json:"templates"` // Invocations of {{template}} and {{block}}.
+	Defines   []Reference `json:"defines"`   // Templates defined with {{define}} and {{block}}.
+}
+
+// Analyze parses files with the delimiters of options and reports the elements they
+// reference. Function names are not resolved, so templates calling unknown functions
+// can still be analyzed.
+func Analyze(files tmpl.Files, options tmpl.Options) (*Report, error) {
+	trees, err := Parse(files, options.LeftDelim, options.RightDelim)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{
+		Fields:    []Reference{},
+		Variables: []Reference{},
+		Functions: []Reference{},
+		Templates: []Reference{},
+		Defines:   []Reference{},
+	}
+	for _, tree := range trees {
+		w := walker{report: r, tree: tree, src: files[tree.ParseName]}
+		if _, isFile := files[tree.Name]; !isFile {
+			r.Defines = append(r.Defines, w.reference(tree.Name, tree.Root))
+		}
+		w.walk(tree.Root)
+	}
+	for _, refs := range [][]Reference{r.Fields, r.Variables, r.Functions, r.Templates, r.Defines} {
+		slices.SortStableFunc(refs, compareReferences)
+	}
+	return r, nil
+}
+
+func compareReferences(a, b Reference) int {
+	return cmp.Or(strings.Compare(a.Template, b.Template), cmp.Compare(a.Offset, b.Offset))
+}
+
+// Parse parses files into parse trees, skipping the check that called functions are
+// defined. The returned trees include those of {{define}} blocks, sorted by name.
+// Empty delimiters select the defaults.
+func Parse(files tmpl.Files, leftDelim, rightDelim string) ([]*parse.Tree, error) {
+	treeSet := make(map[string]*parse.Tree)
+	for _, name := range files.Names() {
+		t := parse.New(name)
+		t.Mode = parse.SkipFuncCheck
+		if _, err := t.Parse(string(files[name]), leftDelim, rightDelim, treeSet); err != nil {
+			return nil, err
+		}
+	}
+
+	trees := make([]*parse.Tree, 0, len(treeSet))
+	for _, tree := range treeSet {
+		trees = append(trees, tree)
+	}
+	slices.SortFunc(trees, func(a, b *parse.Tree) int { return strings.Compare(a.Name, b.Name) })
+	return trees, nil
+}
+
+type walker struct {
+	report *Report
+	tree   *parse.Tree
+	src    []byte
+}
+
+func (w *walker) reference(name string, n parse.Node) Reference {
+	return w.referenceAt(name, int(n.Position()))
+}
+
+func (w *walker) referenceAt(name string, offset int) Reference {
+	line, column := diagnostic.PositionOf(w.src, offset)
+	return Reference{
+		Name:     name,
+		Template: w.tree.ParseName,
+		Line:     line,
+		Column:   column,
+		Offset:   offset,
+	}
+}
+
+//nolint:cyclop // One case per node type.
+func (w *walker) walk(node parse.Node) {
+	switch n := node.(type) {
+	case nil:
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			w.walk(c)
+		}
+	case *parse.ActionNode:
+		w.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, v := range n.Decl {
+			w.walk(v)
+		}
+		for _, c := range n.Cmds {
+			w.walk(c)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			w.walk(arg)
+		}
+	case *parse.FieldNode:
+		offset := int(n.Pos)
+		if len(n.Ident) > 1 {
+			// The parser positions fields with several names, such as .A.B, at the second.
+			offset -= len(n.Ident[0]) + 1
+		}
+		w.report.Fields = append(w.report.Fields, w.referenceAt(n.String(), offset))
+	case *parse.ChainNode:
+		w.walk(n.Node)
+		w.report.Fields = append(w.report.Fields, w.reference(n.String(), n))
+	case *parse.VariableNode:
+		offset := int(n.Pos)
+		if len(n.Ident) > 1 {
+			// The parser positions variables with fields, such as $x.Y, at the first field.
+			offset -= len(n.Ident[0])
+		}
+		w.report.Variables = append(w.report.Variables, w.referenceAt(n.String(), offset))
+	case *parse.IdentifierNode:
+		w.report.Functions = append(w.report.Functions, w.reference(n.Ident, n))
+	case *parse.IfNode:
+		w.walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		w.walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		w.walkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		w.report.Templates = append(w.report.Templates, w.reference(n.Name, n))
+		w.walk(n.Pipe)
+	}
+}
+
+func (w *walker) walkBranch(n *parse.BranchNode) {
+	w.walk(n.Pipe)
+	w.walk(n.List)
+	w.walk(n.ElseList)
+}
@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func names(refs []Reference) []string {
+	out := make([]string, len(refs))
+	for i, r := range refs {
+		out[i] = r.Name
+	}
+	return out
+}
+
+func TestAnalyze(t *testing.T) {
+	files := tmpl.Files{
+		"index.tmpl": []byte(`{{template "header.tmpl" .}}
+{{range $i, $u := .Users}}{{$u.Name | upper}}{{end}}
+{{with .Site}}{{(.Owner).Email}}{{end}}
+{{block "footer" .}}{{unknownFunc .Year}}{{end}}`),
+		"header.tmpl": []byte(`{{define "title"}}{{.Title}}{{end}}`),
+	}
+
+	r, err := Analyze(files, tmpl.Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{".Title", ".Users", ".Site", ".Owner", "(.Owner).Email", ".Year"}, names(r.Fields))
+	assert.Equal(t, []string{"$i", "$u", "$u.Name"}, names(r.Variables))
+	assert.Equal(t, []string{"upper", "unknownFunc"}, names(r.Functions))
+	assert.Equal(t, []string{"header.tmpl", "footer"}, names(r.Templates))
+	assert.Equal(t, []string{"title", "footer"}, names(r.Defines))
+
+	assert.Equal(t, Reference{
+		Name:     "$u.Name",
+		Template: "index.tmpl",
+		Line:     2,
+		Column:   29,
+		Offset:   57,
+	}, r.Variables[2])
+	assert.Equal(t, "header.tmpl", r.Fields[0].Template)
+	assert.Equal(t, "index.tmpl", r.Fields[1].Template)
+}
+
+func TestAnalyze_FieldPosition(t *testing.T) {
+	r, err := Analyze(tmpl.Files{"a.tmpl": []byte("Hi {{.User.Name}}\n{{.A.B.C}}")}, tmpl.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, Reference{
+		Name:     ".User.Name",
+		Template: "a.tmpl",
+		Line:     1,
+		Column:   6,
+		Offset:   5,
+	}, r.Fields[0])
+	assert.Equal(t, 2, r.Fields[1].Line)
+	assert.Equal(t, 3, r.Fields[1].Column)
+}
+
+func TestAnalyze_Delims(t *testing.T) {
+	r, err := Analyze(tmpl.Files{"a.tmpl": []byte("{{ literal }} [[.x]]")}, tmpl.Options{LeftDelim: "[[", RightDelim: "]]"})
+	require.NoError(t, err)
+	require.Len(t, r.Fields, 1)
+	assert.Equal(t, ".x", r.Fields[0].Name)
+	assert.Equal(t, 17, r.Fields[0].Column)
+	assert.Empty(t, r.Functions)
+}
+
+func TestAnalyze_Empty(t *testing.T) {
+	r, err := Analyze(tmpl.Files{"a.tmpl": []byte("plain text")}, tmpl.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, &Report{
+		Fields:    []Reference{},
+		Variables: []Reference{},
+		Functions: []Reference{},
+		Templates: []Reference{},
+		Defines:   []Reference{},
+	}, r)
+}
+
+func TestAnalyze_ParseError(t *testing.T) {
+	_, err := Analyze(tmpl.Files{"a.tmpl": []byte("{{.Name")}, tmpl.Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "template: a.tmpl:1: unclosed action")
+}
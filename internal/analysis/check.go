@@ -0,0 +1,371 @@
+package analysis
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// Codes of the problems reported by [Check].
+const (
+	CodeUnknownField      = "UnknownField"      // A map has no entry for a field.
+	CodeNilField          = "NilField"          // A field is evaluated on a null value.
+	CodeScalarField       = "ScalarField"       // A field is evaluated on a value that has no fields.
+	CodeNotIterable       = "NotIterable"       // A range pipeline does not produce an iterable value.
+	CodeWrongArgCount     = "WrongArgCount"     // A function is called with the wrong number of arguments.
+	CodeUndefinedFunction = "UndefinedFunction" // A function is not defined.
+	CodeUndefinedTemplate = "UndefinedTemplate" // A {{template}} invokes an undefined template.
+)
+
+// arity is the number of arguments a function accepts. A negative max means the
+// function is variadic.
+type arity struct{ min, max int }
+
+// builtinArities holds the arities of the predefined template functions.
+var builtinArities = map[string]arity{
+	"and":      {1, -1},
+	"call":     {1, -1},
+	"eq":       {1, -1},
+	"ge":       {2, 2},
+	"gt":       {2, 2},
+	"html":     {0, -1},
+	"index":    {1, -1},
+	"js":       {0, -1},
+	"le":       {2, 2},
+	"len":      {1, 1},
+	"lt":       {2, 2},
+	"ne":       {2, 2},
+	"not":      {1, 1},
+	"or":       {1, -1},
+	"print":    {0, -1},
+	"printf":   {1, -1},
+	"println":  {0, -1},
+	"slice":    {1, -1},
+	"urlquery": {0, -1},
+}
+
+// Check reports, as warnings, the problems that executing the entry template of files
+// with data is likely to run into: unknown field paths, ranging over values that are
+// not iterable, and calls of undefined functions or with the wrong number of
+// arguments, based on the signatures in funcs. Data is expected to be a value
+// produced by one of the [codec] decoders.
+//
+// The entry template is chosen as by [tmpl.Entry], from options.Entry. The delimiters
+// are also taken from options.
+func Check(files tmpl.Files, options tmpl.Options, data any, funcs template.FuncMap) ([]diagnostic.Diagnostic, error) {
+	trees, err := Parse(files, options.LeftDelim, options.RightDelim)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{
+		files:   files,
+		funcs:   funcs,
+		trees:   make(map[string]*parse.Tree, len(trees)),
+		visited: make(map[visit]bool),
+		diags:   []diagnostic.Diagnostic{},
+	}
+	for _, tree := range trees {
+		c.trees[tree.Name] = tree
+	}
+
+	entry := options.Entry
+	if entry == "" {
+		if len(files) != 1 {
+			return nil, tmpl.ErrNoEntry
+		}
+		entry = files.Names()[0]
+	}
+	tree, ok := c.trees[entry]
+	if !ok {
+		return nil, fmt.Errorf("template %q is not defined", entry)
+	}
+
+	c.checkTree(tree, shapeOf(data))
+	slices.SortStableFunc(c.diags, func(a, b diagnostic.Diagnostic) int {
+		return cmp.Or(strings.Compare(a.Template, b.Template), cmp.Compare(a.Offset, b.Offset))
+	})
+	return c.diags, nil
+}
+
+// visit identifies a template checked with a given dot, to stop recursive templates.
+type visit struct {
+	name string
+	dot  *shape
+}
+
+type variable struct {
+	name  string
+	shape *shape
+}
+
+type checker struct {
+	files   tmpl.Files
+	funcs   template.FuncMap
+	trees   map[string]*parse.Tree
+	visited map[visit]bool
+	diags   []diagnostic.Diagnostic
+
+	tree *parse.Tree // Tree being checked.
+	vars []variable  // Variables in scope, innermost last.
+}
+
+func (c *checker) checkTree(tree *parse.Tree, dot *shape) {
+	key := visit{tree.Name, dot}
+	if c.visited[key] {
+		return
+	}
+	c.visited[key] = true
+
+	prevTree, prevVars := c.tree, c.vars
+	c.tree, c.vars = tree, []variable{{"$", dot}}
+	c.walk(tree.Root, dot)
+	c.tree, c.vars = prevTree, prevVars
+}
+
+func (c *checker) warnf(offset int, code, format string, args ...any) {
+	line, column := diagnostic.PositionOf(c.files[c.tree.ParseName], offset)
+	c.diags = append(c.diags, diagnostic.Diagnostic{
+		Stage:    diagnostic.StageCheck,
+		Severity: diagnostic.SeverityWarning,
+		Template: c.tree.ParseName,
+		Line:     line,
+		Column:   column,
+		Offset:   offset,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) lookup(name string) *shape {
+	for i := len(c.vars) - 1; i >= 0; i-- {
+		if c.vars[i].name == name {
+			return c.vars[i].shape
+		}
+	}
+	return unknownShape
+}
+
+func (c *checker) walk(node parse.Node, dot *shape) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.evalPipe(n.Pipe, dot)
+	case *parse.IfNode:
+		mark := len(c.vars)
+		c.evalPipe(n.Pipe, dot)
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+	case *parse.WithNode:
+		mark := len(c.vars)
+		c.walk(n.List, c.evalPipe(n.Pipe, dot))
+		c.walk(n.ElseList, dot)
+		c.vars = c.vars[:mark]
+	case *parse.RangeNode:
+		c.checkRange(n, dot)
+	case *parse.TemplateNode:
+		arg := nilShape
+		if n.Pipe != nil {
+			arg = c.evalPipe(n.Pipe, dot)
+		}
+		tree, ok := c.trees[n.Name]
+		if !ok {
+			c.warnf(int(n.Pos), CodeUndefinedTemplate, "no such template %q", n.Name)
+			return
+		}
+		c.checkTree(tree, arg)
+	}
+}
+
+func (c *checker) checkRange(n *parse.RangeNode, dot *shape) {
+	mark := len(c.vars)
+	defer func() { c.vars = c.vars[:mark] }()
+
+	val := c.evalCmds(n.Pipe.Cmds, dot)
+	key, elem := unknownShape, unknownShape
+	switch val.kind {
+	case kindList:
+		key, elem = intShape, val.elem
+	case kindMap:
+		key, elem = stringShape, nil
+		for _, f := range val.fields {
+			elem = merge(elem, f)
+		}
+		if elem == nil {
+			elem = unknownShape
+		}
+	case kindInt:
+		key, elem = intShape, intShape
+	case kindBool, kindFloat, kindString:
+		c.warnf(int(n.Pipe.Pos), CodeNotIterable, "range can't iterate over %s", val.kind)
+	case kindUnknown, kindNil:
+	}
+
+	switch len(n.Pipe.Decl) {
+	case 1:
+		c.vars = append(c.vars, variable{n.Pipe.Decl[0].Ident[0], elem})
+	case 2:
+		c.vars = append(c.vars,
+			variable{n.Pipe.Decl[0].Ident[0], key},
+			variable{n.Pipe.Decl[1].Ident[0], elem},
+		)
+	}
+	c.walk(n.List, elem)
+	c.walk(n.ElseList, dot)
+}
+
+// evalPipe checks the pipeline and returns the shape of its result, declaring any
+// variables it introduces.
+func (c *checker) evalPipe(pipe *parse.PipeNode, dot *shape) *shape {
+	if pipe == nil {
+		return unknownShape
+	}
+	val := c.evalCmds(pipe.Cmds, dot)
+	if !pipe.IsAssign {
+		for _, v := range pipe.Decl {
+			c.vars = append(c.vars, variable{v.Ident[0], val})
+		}
+	}
+	return val
+}
+
+func (c *checker) evalCmds(cmds []*parse.CommandNode, dot *shape) *shape {
+	val := unknownShape
+	for i, cmd := range cmds {
+		val = c.evalCmd(cmd, dot, i > 0)
+	}
+	return val
+}
+
+// evalCmd checks the command and returns the shape of its result. If piped is set,
+// the command receives the result of the previous command as its final argument.
+func (c *checker) evalCmd(cmd *parse.CommandNode, dot *shape, piped bool) *shape {
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		for _, arg := range cmd.Args[1:] {
+			c.evalArg(arg, dot)
+		}
+		c.checkCall(ident, len(cmd.Args)-1+btoi(piped))
+		return unknownShape
+	}
+	for _, arg := range cmd.Args[1:] {
+		c.evalArg(arg, dot)
+	}
+	return c.evalArg(cmd.Args[0], dot)
+}
+
+func (c *checker) evalArg(arg parse.Node, dot *shape) *shape {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		offset := int(n.Pos)
+		if len(n.Ident) > 1 {
+			// The parser positions fields with several names, such as .A.B, at the second.
+			offset -= len(n.Ident[0]) + 1
+		}
+		return c.evalFields(dot, n.Ident, n.String(), offset)
+	case *parse.VariableNode:
+		offset := int(n.Pos)
+		if len(n.Ident) > 1 {
+			// The parser positions variables with fields, such as $x.Y, at the first field.
+			offset -= len(n.Ident[0])
+		}
+		return c.evalFields(c.lookup(n.Ident[0]), n.Ident[1:], n.String(), offset)
+	case *parse.ChainNode:
+		return c.evalFields(c.evalArg(n.Node, dot), n.Field, n.String(), int(n.Pos))
+	case *parse.PipeNode:
+		return c.evalPipe(n, dot)
+	case *parse.IdentifierNode:
+		c.checkCall(n, 0)
+		return unknownShape
+	case *parse.StringNode:
+		return stringShape
+	case *parse.BoolNode:
+		return boolShape
+	case *parse.NilNode:
+		return nilShape
+	case *parse.NumberNode:
+		if n.IsInt {
+			return intShape
+		}
+		return floatShape
+	default:
+		return unknownShape
+	}
+}
+
+// evalFields returns the shape reached by following the field names from s,
+// reporting the first field that cannot be evaluated.
+func (c *checker) evalFields(s *shape, fields []string, path string, offset int) *shape {
+	for _, name := range fields {
+		switch s.kind {
+		case kindUnknown:
+			return unknownShape
+		case kindMap:
+			next, ok := s.fields[name]
+			if !ok {
+				c.warnf(offset, CodeUnknownField, "%s: map has no entry for key %q", path, name)
+				return unknownShape
+			}
+			s = next
+		case kindNil:
+			c.warnf(offset, CodeNilField, "%s: can't evaluate field %s of null value", path, name)
+			return unknownShape
+		case kindBool, kindInt, kindFloat, kindString, kindList:
+			c.warnf(offset, CodeScalarField, "%s: can't evaluate field %s in %s", path, name, s.kind)
+			return unknownShape
+		}
+	}
+	return s
+}
+
+// checkCall reports calls of undefined functions, or calls with the wrong number of arguments.
+func (c *checker) checkCall(ident *parse.IdentifierNode, argc int) {
+	a, ok := c.arity(ident.Ident)
+	if !ok {
+		c.warnf(int(ident.Pos), CodeUndefinedFunction, "function %q not defined", ident.Ident)
+		return
+	}
+	switch {
+	case a.max < 0 && argc < a.min:
+		c.warnf(int(ident.Pos), CodeWrongArgCount, "wrong number of args for %s: want at least %d got %d", ident.Ident, a.min, argc)
+	case a.max >= 0 && argc != a.min:
+		c.warnf(int(ident.Pos), CodeWrongArgCount, "wrong number of args for %s: want %d got %d", ident.Ident, a.min, argc)
+	}
+}
+
+func (c *checker) arity(name string) (arity, bool) {
+	if fn, ok := c.funcs[name]; ok {
+		typ := reflect.TypeOf(fn)
+		if typ == nil || typ.Kind() != reflect.Func {
+			return arity{}, false
+		}
+		if typ.IsVariadic() {
+			return arity{typ.NumIn() - 1, -1}, true
+		}
+		return arity{typ.NumIn(), typ.NumIn()}, true
+	}
+	a, ok := builtinArities[name]
+	return a, ok
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	data := map[string]any{
+		"User":  map[string]any{"Name": "Gopher", "Tags": []any{"a", "b"}},
+		"Items": []any{map[string]any{"ID": 1.0}, map[string]any{"ID": 2.0, "Extra": true}},
+		"Count": 3.0,
+		"Limit": 3,
+		"Owner": nil,
+		"Users": map[string]any{"a": map[string]any{"Name": "A"}, "b": map[string]any{"Name": "B", "Admin": true}},
+		"Empty": map[string]any{},
+	}
+
+	tests := []struct {
+		name  string
+		src   string
+		codes []string
+		msgs  []string
+	}{
+		{
+			name: "Valid",
+			src: `{{.User.Name | upper}}{{range $i, $t := .User.Tags}}{{$i}}{{$t}}{{end}}` +
+				`{{range .Items}}{{.ID}}{{.Extra}}{{end}}{{range .Limit}}{{.}}{{end}}` +
+				`{{with .User}}{{.Name}}{{end}}{{$u := .User}}{{$u.Name}}{{add 1 2 3}}{{printf "%d" 1}}`,
+		},
+		{
+			name:  "UnknownField",
+			src:   `{{.Usr.Name}}{{with .User}}{{.Email}}{{end}}`,
+			codes: []string{CodeUnknownField, CodeUnknownField},
+			msgs:  []string{`.Usr.Name: map has no entry for key "Usr"`, `.Email: map has no entry for key "Email"`},
+		},
+		{
+			name:  "RangeMap",
+			src:   `{{range $k, $u := .Users}}{{$k}}{{.Name}}{{.Admin}}{{$u.Name}}{{.kk}}{{end}}{{range .Empty}}{{.kk}}{{end}}`,
+			codes: []string{CodeUnknownField},
+			msgs:  []string{`.kk: map has no entry for key "kk"`},
+		},
+		{
+			name:  "NilAndScalarFields",
+			src:   `{{.Owner.Name}}{{.User.Name.First}}`,
+			codes: []string{CodeNilField, CodeScalarField},
+			msgs:  []string{".Owner.Name: can't evaluate field Name of null value", ".User.Name.First: can't evaluate field First in string"},
+		},
+		{
+			name:  "NotIterable",
+			src:   `{{range .User.Name}}{{end}}{{range .Count}}{{end}}`,
+			codes: []string{CodeNotIterable, CodeNotIterable},
+			msgs:  []string{"range can't iterate over string", "range can't iterate over number"},
+		},
+		{
+			name:  "Arity",
+			src:   `{{upper "a" "b"}}{{"a" | upper}}{{.User.Name | replaceAll "a"}}{{printf}}{{nope 1}}`,
+			codes: []string{CodeWrongArgCount, CodeWrongArgCount, CodeWrongArgCount, CodeUndefinedFunction},
+			msgs: []string{
+				"wrong number of args for upper: want 1 got 2",
+				"wrong number of args for replaceAll: want 3 got 2",
+				"wrong number of args for printf: want at least 1 got 0",
+				`function "nope" not defined`,
+			},
+		},
+		{
+			name:  "Templates",
+			src:   `{{define "user"}}{{.Name}}{{.Age}}{{end}}{{template "user" .User}}{{template "missing"}}`,
+			codes: []string{CodeUnknownField, CodeUndefinedTemplate},
+			msgs:  []string{`.Age: map has no entry for key "Age"`, `no such template "missing"`},
+		},
+		{
+			name: "RecursiveTemplate",
+			src:  `{{define "r"}}{{template "r" .}}{{end}}{{template "r" .}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags, err := Check(tmpl.Files{"t": []byte(tt.src)}, tmpl.Options{}, data, tmpl.TemplateFuncs())
+			require.NoError(t, err)
+			var codes, msgs []string
+			for _, d := range diags {
+				assert.Equal(t, diagnostic.StageCheck, d.Stage)
+				assert.Equal(t, diagnostic.SeverityWarning, d.Severity)
+				codes = append(codes, d.Code)
+				msgs = append(msgs, d.Message)
+			}
+			assert.Equal(t, tt.codes, codes)
+			assert.Equal(t, tt.msgs, msgs)
+		})
+	}
+}
+
+func TestCheck_Position(t *testing.T) {
+	files := tmpl.Files{"t": []byte("line one\n  {{$x := .}}{{$x.Nope}}")}
+	diags, err := Check(files, tmpl.Options{}, map[string]any{}, nil)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "t", diags[0].Template)
+	assert.Equal(t, 2, diags[0].Line)
+	assert.Equal(t, 16, diags[0].Column)
+	assert.Equal(t, 24, diags[0].Offset)
+}
+
+func TestCheck_FieldPosition(t *testing.T) {
+	diags, err := Check(tmpl.Files{"t": []byte("{{.Usr.Name}}")}, tmpl.Options{}, map[string]any{}, nil)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, 1, diags[0].Line)
+	assert.Equal(t, 3, diags[0].Column)
+	assert.Equal(t, 2, diags[0].Offset)
+}
+
+func TestCheck_Entry(t *testing.T) {
+	files := tmpl.Files{"a": []byte(`{{.A}}`), "b": []byte(`{{.B}}`)}
+
+	_, err := Check(files, tmpl.Options{}, nil, nil)
+	require.ErrorIs(t, err, tmpl.ErrNoEntry)
+
+	_, err = Check(files, tmpl.Options{Entry: "c"}, nil, nil)
+	require.EqualError(t, err, `template "c" is not defined`)
+
+	diags, err := Check(files, tmpl.Options{Entry: "b"}, map[string]any{"A": 1}, nil)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "b", diags[0].Template)
+}
+
+func TestCheck_Delims(t *testing.T) {
+	files := tmpl.Files{"t": []byte(`{{ literal }} [[.A]] [[.B]]`)}
+	diags, err := Check(files, tmpl.Options{LeftDelim: "[[", RightDelim: "]]"}, map[string]any{"A": 1}, nil)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, 24, diags[0].Column)
+}
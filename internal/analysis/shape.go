@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"maps"
+	"reflect"
+)
+
+// kind classifies the values found in decoded data.
+type kind uint8
+
+const (
+	kindUnknown kind = iota // Not inferred; never reported on.
+	kindNil
+	kindBool
+	kindInt
+	kindFloat
+	kindString
+	kindMap
+	kindList
+)
+
+func (k kind) String() string {
+	return [...]string{"unknown", "null", "bool", "integer", "number", "string", "map", "list"}[k]
+}
+
+// shape is the inferred structure of a decoded value.
+type shape struct {
+	kind   kind
+	fields map[string]*shape // Map entries, for kindMap.
+	elem   *shape            // Merged element shape, for kindList.
+}
+
+// Shapes without structure are shared, so that they can be compared by identity.
+var (
+	unknownShape = &shape{kind: kindUnknown}
+	nilShape     = &shape{kind: kindNil}
+	boolShape    = &shape{kind: kindBool}
+	intShape     = &shape{kind: kindInt}
+	floatShape   = &shape{kind: kindFloat}
+	stringShape  = &shape{kind: kindString}
+)
+
+// shapeOf infers the shape of v, a value produced by one of the [codec] decoders.
+func shapeOf(v any) *shape {
+	if v == nil {
+		return nilShape
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Bool:
+		return boolShape
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return intShape
+	case reflect.Float32, reflect.Float64:
+		return floatShape
+	case reflect.String:
+		return stringShape
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return unknownShape
+		}
+		s := &shape{kind: kindMap, fields: make(map[string]*shape, rv.Len())}
+		for iter := rv.MapRange(); iter.Next(); {
+			s.fields[iter.Key().String()] = shapeOf(iter.Value().Interface())
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		var elem *shape
+		for i := range rv.Len() {
+			elem = merge(elem, shapeOf(rv.Index(i).Interface()))
+		}
+		if elem == nil {
+			elem = unknownShape
+		}
+		return &shape{kind: kindList, elem: elem}
+	default:
+		return unknownShape
+	}
+}
+
+// merge returns a shape that accepts everything accepted by a and b, so that a field
+// present in any element of a list is considered known.
+func merge(a, b *shape) *shape {
+	switch {
+	case a == nil || a.kind == kindNil:
+		return b
+	case b == nil || b.kind == kindNil:
+		return a
+	case a.kind != b.kind:
+		return unknownShape
+	case a.kind == kindMap:
+		fields := maps.Clone(a.fields)
+		for k, v := range b.fields {
+			fields[k] = merge(fields[k], v)
+		}
+		return &shape{kind: kindMap, fields: fields}
+	case a.kind == kindList:
+		return &shape{kind: kindList, elem: merge(a.elem, b.elem)}
+	default:
+		return a
+	}
+}
@@ -1,6 +1,6 @@
 // Package codec provides encoding and decoding functions for multiple data formats.
 //
-// Supported formats: JSON, YAML, TOML.
+// Supported formats: JSON, YAML, TOML, XML, CSV, TSV.
 package codec
 
 import (
@@ -8,8 +8,7 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/BurntSushi/toml"
-	"gopkg.in/yaml.v3"
+	"github.com/bartventer/go-template-playground/internal/util"
 )
 
 // Format represents a supported data format.
@@ -20,40 +19,79 @@ const (
 	FormatJSON Format = "json"
 	FormatYAML Format = "yaml"
 	FormatTOML Format = "toml"
+	FormatXML  Format = "xml"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
 )
 
-// Decoder reads JSON, YAML, or TOML values from an input stream.
+// Formats returns the supported formats.
+func Formats() []Format {
+	return []Format{FormatJSON, FormatYAML, FormatTOML, FormatXML, FormatCSV, FormatTSV}
+}
+
+// DecoderOptions holds configuration settings for the decoder.
+type DecoderOptions struct {
+	CSV CSVOptions // Settings of the CSV and TSV formats.
+}
+
+// Unmarshalls the javascript object into a DecoderOptions struct.
+func (o *DecoderOptions) UnmarshalJS(data util.JSValuer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	if csv := data.Get("csv"); csv.Truthy() {
+		return o.CSV.UnmarshalJS(csv)
+	}
+	return nil
+}
+
+// Decoder reads JSON, YAML, TOML, XML, CSV, or TSV values from an input stream.
 type Decoder struct {
-	r      io.Reader
-	format Format
+	r       io.Reader
+	format  Format
+	options *DecoderOptions
 }
 
 // NewDecoder creates a new decoder.
-func NewDecoder(r io.Reader, format Format) *Decoder {
-	return &Decoder{r: r, format: format}
+func NewDecoder(r io.Reader, format Format, options *DecoderOptions) *Decoder {
+	if options == nil {
+		options = &DecoderOptions{}
+	}
+	return &Decoder{r: r, format: format, options: options}
 }
 
 // Decode reads the data from the input stream.
+//
+// If the underlying decoder reports where in the input decoding failed, the
+// returned error is a [*DecodeError].
 func (d *Decoder) Decode(v interface{}) error {
-	return decode(d.r, v, d.format)
+	if err := decode(d.r, v, d.format, d.options); err != nil {
+		return locateError(err, d.format)
+	}
+	return nil
 }
 
 // decode reads the data from the input stream in the specified format.
-func decode(r io.Reader, v interface{}, format Format) error {
+func decode(r io.Reader, v interface{}, format Format, options *DecoderOptions) error {
 	switch format {
 	case FormatJSON:
-		return json.NewDecoder(r).Decode(v)
+		return decodeJSON(r, v)
 	case FormatYAML:
-		return yaml.NewDecoder(r).Decode(v)
+		return decodeYAML(r, v)
 	case FormatTOML:
-		_, err := toml.NewDecoder(r).Decode(v)
-		return err
+		return decodeTOML(r, v)
+	case FormatXML:
+		return decodeXML(r, v)
+	case FormatCSV, FormatTSV:
+		return decodeCSV(r, v, format, &options.CSV)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
-// Encoder writes JSON, YAML, or TOML values to an output stream.
+// Encoder writes JSON, YAML, TOML, XML, CSV, or TSV values to an output stream.
 type Encoder struct {
 	w       io.Writer
 	format  Format
@@ -84,13 +122,13 @@ func encode(w io.Writer, data interface{}, format Format, options *EncoderOption
 		e.SetIndent("", indent)
 		return e.Encode(data)
 	case FormatYAML:
-		e := yaml.NewEncoder(w)
-		e.SetIndent(options.IndentSize)
-		return e.Encode(data)
+		return encodeYAML(w, data, options)
 	case FormatTOML:
-		e := toml.NewEncoder(w)
-		e.Indent = indent
-		return e.Encode(data)
+		return encodeTOML(w, data, indent)
+	case FormatXML:
+		return encodeXML(w, data, indent)
+	case FormatCSV, FormatTSV:
+		return encodeCSV(w, data, format, &options.CSV)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
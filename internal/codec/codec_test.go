@@ -37,6 +37,13 @@ func TestDecoder_Decode(t *testing.T) {
 			map[string]interface{}{"key": "value"},
 			false,
 		},
+		{
+			"XML",
+			FormatXML,
+			"<key>value</key>",
+			map[string]interface{}{"key": "value"},
+			false,
+		},
 		{
 			"Unsupported",
 			"unsupported",
@@ -49,7 +56,7 @@ func TestDecoder_Decode(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := bytes.NewReader([]byte(tt.data))
-			d := NewDecoder(r, tt.format)
+			d := NewDecoder(r, tt.format, nil)
 			var got map[string]interface{}
 			err := d.Decode(&got)
 			if (err != nil) != tt.wantErr {
@@ -96,6 +103,14 @@ func TestEncoder_Encode(t *testing.T) {
 			"key = \"value\"\n",
 			false,
 		},
+		{
+			"XML",
+			FormatXML,
+			map[string]interface{}{"key": "value"},
+			&EncoderOptions{InsertSpaces: true, IndentSize: 2},
+			"<key>value</key>\n",
+			false,
+		},
 		{
 			"Unsupported",
 			"unsupported",
@@ -0,0 +1,324 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bartventer/go-template-playground/internal/util"
+)
+
+// CSVOptions holds the settings of the CSV and TSV formats.
+//
+// A CSV document with a header decodes as an array of maps, one per record, keyed by
+// the column names of the header. Without a header, it decodes as an array of arrays.
+// Fields are strings, unless InferTypes is set.
+//
+// An array of maps encodes as a header of the union of their keys, in the order in
+// which they first appear, and a record per map. Nested maps are flattened into columns named after their path,
+// as in "address.city", and nested arrays are encoded as JSON. An array of arrays
+// encodes as a record per array, and a single map as a single record.
+type CSVOptions struct {
+	Delimiter  rune // Field delimiter; defaults to ',' for CSV and '\t' for TSV.
+	NoHeader   bool // The first record is data rather than column names.
+	InferTypes bool // Decode integers, floats and booleans as such rather than as strings.
+	LazyQuotes bool // Accept quotes in unquoted fields and unescaped quotes in quoted fields.
+	QuoteAll   bool // Quote every encoded field, rather than only those that need it.
+}
+
+// delimiter returns the delimiter of format.
+func (o *CSVOptions) delimiter(format Format) (rune, error) {
+	d := o.Delimiter
+	if d == 0 {
+		d = ','
+		if format == FormatTSV {
+			d = '\t'
+		}
+	}
+	if d == '"' || d == '\r' || d == '\n' || d == utf8.RuneError || !utf8.ValidRune(d) {
+		return 0, fmt.Errorf("csv: invalid delimiter %q", d)
+	}
+	return d, nil
+}
+
+// Unmarshalls the javascript object into a CSVOptions struct.
+func (o *CSVOptions) UnmarshalJS(data util.JSValuer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	if delimiter := data.Get("delimiter"); delimiter.Truthy() {
+		s := delimiter.String()
+		if utf8.RuneCountInString(s) != 1 {
+			return fmt.Errorf("csv delimiter must be a single character, got %q", s)
+		}
+		o.Delimiter, _ = utf8.DecodeRuneInString(s)
+	}
+	o.NoHeader = data.Get("noHeader").Truthy()
+	o.InferTypes = data.Get("inferTypes").Truthy()
+	o.LazyQuotes = data.Get("lazyQuotes").Truthy()
+	o.QuoteAll = data.Get("quoteAll").Truthy()
+	return nil
+}
+
+// decodeCSV reads a CSV document in format from r and stores its generic value in v.
+func decodeCSV(r io.Reader, v any, format Format, options *CSVOptions) error {
+	delimiter, err := options.delimiter(format)
+	if err != nil {
+		return err
+	}
+	cr := csv.NewReader(skipBOM(r))
+	cr.Comma = delimiter
+	cr.LazyQuotes = options.LazyQuotes
+
+	var (
+		header []string
+		rows   = []any{}
+		empty  = true
+	)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		empty = false
+		if header == nil && !options.NoHeader {
+			if err := checkHeader(record); err != nil {
+				line, _ := cr.FieldPos(0)
+				return &csv.ParseError{StartLine: line, Line: line, Column: 1, Err: err}
+			}
+			header = record
+			continue
+		}
+		if options.NoHeader {
+			row := make([]any, len(record))
+			for i, field := range record {
+				row[i] = csvField(field, options.InferTypes)
+			}
+			rows = append(rows, row)
+			continue
+		}
+		row := NewMap(len(header))
+		for i, field := range record {
+			row.Set(header[i], csvField(field, options.InferTypes))
+		}
+		rows = append(rows, row)
+	}
+	if empty {
+		return io.EOF
+	}
+	return setValue(v, rows)
+}
+
+// skipBOM returns r without the UTF-8 byte order mark that spreadsheets often write.
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, _ := br.Peek(3); bytes.Equal(b, []byte("\xef\xbb\xbf")) {
+		_, _ = br.Discard(3)
+	}
+	return br
+}
+
+// checkHeader reports an error if the header has empty or duplicate column names.
+func checkHeader(header []string) error {
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		switch {
+		case name == "":
+			return fmt.Errorf("empty column name in column %d", i+1)
+		case seen[name]:
+			return fmt.Errorf("duplicate column name %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// csvNumberRe matches the decimal numbers that are inferred as numbers. Leading zeros
+// are excluded, so that codes such as "007" stay strings.
+var csvNumberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// csvField returns the value of field, inferring its type if infer is set.
+func csvField(field string, infer bool) any {
+	if !infer {
+		return field
+	}
+	switch field {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if !csvNumberRe.MatchString(field) {
+		return field
+	}
+	if n, err := strconv.Atoi(field); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(field, 64); err == nil {
+		return f
+	}
+	return field
+}
+
+// encodeCSV writes data to w as a CSV document in format.
+func encodeCSV(w io.Writer, data any, format Format, options *CSVOptions) error {
+	delimiter, err := options.delimiter(format)
+	if err != nil {
+		return err
+	}
+	data, err = genericValue(data)
+	if err != nil {
+		return err
+	}
+	records, err := csvRecords(data, options.NoHeader)
+	if err != nil {
+		return err
+	}
+
+	if !options.QuoteAll {
+		cw := csv.NewWriter(w)
+		cw.Comma = delimiter
+		if err := cw.WriteAll(records); err != nil {
+			return err
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, record := range records {
+		for i, field := range record {
+			if i > 0 {
+				buf.WriteRune(delimiter)
+			}
+			buf.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`)
+		}
+		buf.WriteByte('\n')
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// csvRecords returns the records of the generic value data, preceded by a header for
+// maps unless noHeader is set.
+func csvRecords(data any, noHeader bool) ([][]string, error) {
+	items, ok := data.([]any)
+	if !ok {
+		if _, isMap := data.(*Map); !isMap {
+			return nil, fmt.Errorf("csv: cannot encode %T; expected an array of maps or arrays", data)
+		}
+		items = []any{data}
+	}
+
+	var (
+		records [][]string
+		rows    []map[string]string
+		header  = csvHeader{seen: make(map[string]bool)}
+	)
+	for i, item := range items {
+		switch item := item.(type) {
+		case *Map:
+			row := make(map[string]string)
+			if err := flattenCSV(row, &header, "", item); err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		case []any:
+			record := make([]string, len(item))
+			for j, x := range item {
+				field, err := csvText(x)
+				if err != nil {
+					return nil, err
+				}
+				record[j] = field
+			}
+			records = append(records, record)
+		default:
+			return nil, fmt.Errorf("csv: cannot encode item %d of type %T; expected a map or an array", i, item)
+		}
+	}
+	if rows == nil {
+		return records, nil
+	}
+	if records != nil {
+		return nil, errors.New("csv: cannot encode a mix of maps and arrays")
+	}
+
+	if !noHeader {
+		records = append(records, header.names)
+	}
+	for _, row := range rows {
+		record := make([]string, len(header.names))
+		for j, column := range header.names {
+			record[j] = row[column]
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// csvHeader holds the column names of a CSV document in the order in which they were
+// added.
+type csvHeader struct {
+	names []string
+	seen  map[string]bool
+}
+
+func (h *csvHeader) add(name string) {
+	if !h.seen[name] {
+		h.seen[name] = true
+		h.names = append(h.names, name)
+	}
+}
+
+// flattenCSV adds the fields of m to row, naming the fields of nested maps after their
+// path from prefix, and adds their names to header.
+func flattenCSV(row map[string]string, header *csvHeader, prefix string, m *Map) error {
+	for k, v := range m.All() {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if nested, ok := v.(*Map); ok {
+			if err := flattenCSV(row, header, k, nested); err != nil {
+				return err
+			}
+			continue
+		}
+		field, err := csvText(v)
+		if err != nil {
+			return err
+		}
+		header.add(k)
+		row[k] = field
+	}
+	return nil
+}
+
+// csvText formats the generic value v as a field.
+func csvText(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case *Map, []any:
+		b, err := json.Marshal(v)
+		return string(b), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,204 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_Decode_CSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		data     string
+		options  CSVOptions
+		expected any
+		errorMsg string
+	}{
+		{
+			name:   "Header",
+			format: FormatCSV,
+			data:   "name,qty,note\napple,3,\"red, sweet\"\npear,10,\n",
+			expected: []any{
+				map[string]any{"name": "apple", "qty": "3", "note": "red, sweet"},
+				map[string]any{"name": "pear", "qty": "10", "note": ""},
+			},
+		},
+		{
+			name:    "InferTypes",
+			format:  FormatCSV,
+			data:    "\xef\xbb\xbfcode,n,x,ok,s\n007,-12,1.5e3,TRUE,1.2.3\n",
+			options: CSVOptions{InferTypes: true},
+			expected: []any{
+				map[string]any{"code": "007", "n": -12, "x": 1500.0, "ok": true, "s": "1.2.3"},
+			},
+		},
+		{
+			name:     "NoHeader",
+			format:   FormatTSV,
+			data:     "a\tb\n1\t2\n",
+			options:  CSVOptions{NoHeader: true, InferTypes: true},
+			expected: []any{[]any{"a", "b"}, []any{1, 2}},
+		},
+		{
+			name:     "Delimiter",
+			format:   FormatCSV,
+			data:     "a;b\n1;2\n",
+			options:  CSVOptions{Delimiter: ';'},
+			expected: []any{map[string]any{"a": "1", "b": "2"}},
+		},
+		{
+			name:     "LazyQuotes",
+			format:   FormatCSV,
+			data:     "a\n5\"\n",
+			options:  CSVOptions{LazyQuotes: true},
+			expected: []any{map[string]any{"a": `5"`}},
+		},
+		{
+			name:     "HeaderOnly",
+			format:   FormatCSV,
+			data:     "a,b\n",
+			expected: []any{},
+		},
+		{
+			name:     "BareQuote",
+			format:   FormatCSV,
+			data:     "a\n5\"\n",
+			errorMsg: "bare \" in non-quoted-field",
+		},
+		{
+			name:     "FieldCount",
+			format:   FormatCSV,
+			data:     "a,b\n1\n",
+			errorMsg: "wrong number of fields",
+		},
+		{
+			name:     "DuplicateColumn",
+			format:   FormatCSV,
+			data:     "a,a\n1,2\n",
+			errorMsg: `duplicate column name "a"`,
+		},
+		{
+			name:     "InvalidDelimiter",
+			format:   FormatCSV,
+			data:     "a\n",
+			options:  CSVOptions{Delimiter: '"'},
+			errorMsg: "invalid delimiter",
+		},
+		{
+			name:     "Empty",
+			format:   FormatCSV,
+			errorMsg: "EOF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			err := NewDecoder(bytes.NewReader([]byte(tt.data)), tt.format, &DecoderOptions{CSV: tt.options}).Decode(&v)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, Plain(v))
+		})
+	}
+}
+
+func TestDecoder_Decode_CSVLocation(t *testing.T) {
+	var v any
+	err := NewDecoder(bytes.NewReader([]byte("a,b\n1,2\n3\n")), FormatCSV, nil).Decode(&v)
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, []Location{{Line: 3, Column: 1, Offset: -1, Message: "wrong number of fields"}}, decodeErr.Locations)
+}
+
+func TestEncoder_Encode_CSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		data     any
+		options  CSVOptions
+		expected string
+		errorMsg string
+	}{
+		{
+			name:   "Maps",
+			format: FormatCSV,
+			data: []any{
+				map[string]any{"name": "apple", "qty": 3, "tags": []any{"red"}},
+				map[string]any{"name": "pear, green", "addr": map[string]any{"city": "Oslo"}},
+			},
+			expected: "name,qty,tags,addr.city\napple,3,\"[\"\"red\"\"]\",\n\"pear, green\",,,Oslo\n",
+		},
+		{
+			name:     "NoHeader",
+			format:   FormatTSV,
+			data:     []map[string]any{{"a": 1, "b": nil}},
+			options:  CSVOptions{NoHeader: true},
+			expected: "1\t\n",
+		},
+		{
+			name:     "Arrays",
+			format:   FormatCSV,
+			data:     []any{[]any{"a", "b"}, []any{1, true}},
+			options:  CSVOptions{Delimiter: ';'},
+			expected: "a;b\n1;true\n",
+		},
+		{
+			name:     "QuoteAll",
+			format:   FormatTSV,
+			data:     map[string]any{"a": `say "hi"`, "b": 2},
+			options:  CSVOptions{QuoteAll: true},
+			expected: "\"a\"\t\"b\"\n\"say \"\"hi\"\"\"\t\"2\"\n",
+		},
+		{
+			name:     "Scalar",
+			format:   FormatCSV,
+			data:     "a",
+			errorMsg: "cannot encode string",
+		},
+		{
+			name:     "Mixed",
+			format:   FormatCSV,
+			data:     []any{[]any{1}, map[string]any{"a": 1}},
+			errorMsg: "cannot encode a mix of maps and arrays",
+		},
+		{
+			name:     "ScalarItem",
+			format:   FormatCSV,
+			data:     []any{1},
+			errorMsg: "cannot encode item 0 of type int",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := NewEncoder(&buf, tt.format, &EncoderOptions{CSV: tt.options}).Encode(tt.data)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestCSVOptions_UnmarshalJS(t *testing.T) {
+	var raw any
+	require.NoError(t, json.Unmarshal([]byte(`{"csv": {"delimiter": "|", "noHeader": true, "inferTypes": true, "lazyQuotes": true, "quoteAll": true}}`), &raw))
+	var options DecoderOptions
+	require.NoError(t, options.UnmarshalJS(util.JSONValue{V: raw}))
+	assert.Equal(t, CSVOptions{Delimiter: '|', NoHeader: true, InferTypes: true, LazyQuotes: true, QuoteAll: true}, options.CSV)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"delimiter": "ab"}`), &raw))
+	err := new(CSVOptions).UnmarshalJS(util.JSONValue{V: raw})
+	assert.EqualError(t, err, `csv delimiter must be a single character, got "ab"`)
+}
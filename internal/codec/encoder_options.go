@@ -17,9 +17,11 @@ const (
 
 // EncoderOptions holds configuration settings for the encoder.
 type EncoderOptions struct {
-	InsertSpaces bool // Use spaces instead of tabs.
-	IndentSize   int  // Number of spaces or tabs to insert per indent.
-	NoIndent     bool // Do not indent the output.
+	InsertSpaces bool       // Use spaces instead of tabs.
+	IndentSize   int        // Number of spaces or tabs to insert per indent.
+	NoIndent     bool       // Do not indent the output.
+	YAMLStream   bool       // Encode a top-level array in YAML as a stream of documents.
+	CSV          CSVOptions // Settings of the CSV and TSV formats.
 }
 
 func (o *EncoderOptions) init() {
@@ -60,5 +62,9 @@ func (o *EncoderOptions) UnmarshalJS(data util.JSValuer) (err error) {
 		o.IndentSize = data.Get("indentSize").Int()
 	}
 	o.NoIndent = data.Get("noIndent").Truthy()
+	o.YAMLStream = data.Get("yamlStream").Truthy()
+	if csv := data.Get("csv"); csv.Truthy() {
+		return o.CSV.UnmarshalJS(csv)
+	}
 	return nil
 }
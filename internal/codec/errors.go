@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Location describes where in the input a decoding problem was found.
+type Location struct {
+	Line    int    // 1-based line, or 0 if unknown.
+	Column  int    // 1-based column, or 0 if unknown.
+	Offset  int    // 0-based byte offset, or -1 if unknown.
+	Message string // Description of the problem at this location.
+}
+
+// DecodeError is returned by [Decoder.Decode] when the underlying decoder reports
+// where in the input decoding failed. Decoders report locations with varying
+// precision: JSON reports only byte offsets, YAML and XML only lines, CSV lines and
+// columns, and TOML all three.
+type DecodeError struct {
+	Format    Format
+	Locations []Location
+	Err       error
+}
+
+func (e *DecodeError) Error() string { return e.Err.Error() }
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+var (
+	yamlSyntaxLineRe = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+	yamlTypeLineRe   = regexp.MustCompile(`^line (\d+): (.*)$`)
+)
+
+// locateError wraps err in a [DecodeError] if the location of the problem
+// can be determined; otherwise err is returned unchanged.
+func locateError(err error, format Format) error {
+	var locs []Location
+
+	var (
+		jsonSyntaxErr *json.SyntaxError
+		jsonTypeErr   *json.UnmarshalTypeError
+		yamlTypeErr   *yaml.TypeError
+		tomlParseErr  toml.ParseError
+		xmlSyntaxErr  *xml.SyntaxError
+		csvParseErr   *csv.ParseError
+	)
+	switch {
+	case errors.As(err, &jsonSyntaxErr):
+		locs = append(locs, jsonLocation(jsonSyntaxErr.Offset, jsonSyntaxErr.Error()))
+	case errors.As(err, &jsonTypeErr):
+		locs = append(locs, jsonLocation(jsonTypeErr.Offset, jsonTypeErr.Error()))
+	case errors.As(err, &yamlTypeErr):
+		for _, msg := range yamlTypeErr.Errors {
+			if loc, ok := yamlLocation(yamlTypeLineRe, msg); ok {
+				locs = append(locs, loc)
+			}
+		}
+	case errors.As(err, &tomlParseErr):
+		locs = append(locs, Location{
+			Line:    tomlParseErr.Position.Line,
+			Column:  tomlParseErr.Position.Col,
+			Offset:  tomlParseErr.Position.Start,
+			Message: tomlParseErr.Message,
+		})
+	case errors.As(err, &xmlSyntaxErr):
+		locs = append(locs, Location{Line: xmlSyntaxErr.Line, Offset: -1, Message: xmlSyntaxErr.Msg})
+	case errors.As(err, &csvParseErr):
+		locs = append(locs, Location{
+			Line:    csvParseErr.Line,
+			Column:  csvParseErr.Column,
+			Offset:  -1,
+			Message: csvParseErr.Err.Error(),
+		})
+	case format == FormatYAML:
+		if loc, ok := yamlLocation(yamlSyntaxLineRe, err.Error()); ok {
+			locs = append(locs, loc)
+		}
+	}
+
+	if len(locs) == 0 {
+		return err
+	}
+	return &DecodeError{Format: format, Locations: locs, Err: err}
+}
+
+// jsonLocation returns the location of a JSON error reported after reading offset bytes.
+func jsonLocation(offset int64, msg string) Location {
+	return Location{Offset: max(int(offset)-1, 0), Message: msg}
+}
+
+func yamlLocation(re *regexp.Regexp, msg string) (Location, bool) {
+	m := re.FindStringSubmatch(msg)
+	if m == nil {
+		return Location{}, false
+	}
+	line, err := strconv.Atoi(m[1])
+	if err != nil {
+		return Location{}, false
+	}
+	return Location{Line: line, Offset: -1, Message: m[2]}, true
+}
@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_Decode_DecodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		want   []Location
+	}{
+		{
+			"JSONSyntax",
+			FormatJSON,
+			"{\n\"a\": 1,\n}",
+			[]Location{{Offset: 10, Message: "invalid character '}' looking for beginning of object key string"}},
+		},
+		{
+			"YAMLSyntax",
+			FormatYAML,
+			"a: 1\n b: 2\n",
+			[]Location{{Line: 2, Offset: -1, Message: "mapping values are not allowed in this context"}},
+		},
+		{
+			"TOMLSyntax",
+			FormatTOML,
+			"a = 1\nb = \n",
+			[]Location{{Line: 2, Column: 5, Offset: 10, Message: "expected value but found '\\n' instead"}},
+		},
+		{
+			"XMLSyntax",
+			FormatXML,
+			"<a>\n<b></c>\n</a>",
+			[]Location{{Line: 2, Offset: -1, Message: "unexpected end element </c>"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			err := NewDecoder(bytes.NewReader([]byte(tt.data)), tt.format, nil).Decode(&v)
+			var decodeErr *DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.format, decodeErr.Format)
+			assert.Equal(t, tt.want, decodeErr.Locations)
+		})
+	}
+}
+
+func TestDecoder_Decode_YAMLTypeErrors(t *testing.T) {
+	var v map[string]int
+	err := NewDecoder(bytes.NewReader([]byte("a: x\nb: y\n")), FormatYAML, nil).Decode(&v)
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	require.Len(t, decodeErr.Locations, 2)
+	assert.Equal(t, 1, decodeErr.Locations[0].Line)
+	assert.Equal(t, 2, decodeErr.Locations[1].Line)
+}
+
+func TestDecoder_Decode_Unlocated(t *testing.T) {
+	var v interface{}
+	err := NewDecoder(bytes.NewReader(nil), FormatJSON, nil).Decode(&v)
+	require.EqualError(t, err, "EOF")
+	assert.NotErrorAs(t, err, new(*DecodeError))
+}
@@ -0,0 +1,94 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// setValue stores the generic value x decoded by one of our own decoders in v. Maps are
+// stored as *Map in a *any.
+func setValue(v any, x any) error {
+	switch p := v.(type) {
+	case *any:
+		*p = x
+		return nil
+	case *map[string]any:
+		if m, ok := Plain(x).(map[string]any); ok {
+			*p = m
+			return nil
+		}
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// genericValue returns v with its maps converted to *Map, and values of other types
+// converted to generic values through JSON. The keys of a *Map keep their order, and
+// those of other maps are sorted.
+func genericValue(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, bool, int, int64, uint64, float64, time.Time:
+		return v, nil
+	case *Map:
+		m := NewMap(v.Len())
+		for k, x := range v.All() {
+			x, err := genericValue(x)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(k, x)
+		}
+		return m, nil
+	case map[string]any:
+		m := NewMap(len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			x, err := genericValue(v[k])
+			if err != nil {
+				return nil, err
+			}
+			m.Set(k, x)
+		}
+		return m, nil
+	case map[any]any:
+		keys := make(map[string]any, len(v))
+		for k, x := range v {
+			keys[fmt.Sprint(k)] = x
+		}
+		return genericValue(keys)
+	case []any:
+		s := make([]any, len(v))
+		for i, x := range v {
+			x, err := genericValue(x)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = x
+		}
+		return s, nil
+	case Documents:
+		return genericValue([]any(v))
+	case []map[string]any:
+		s := make([]any, len(v))
+		for i, x := range v {
+			s[i] = x
+		}
+		return genericValue(s)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var x any
+		if err := decodeJSON(bytes.NewReader(b), &x); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+}
@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// decodeJSON reads a JSON value from r and stores it in v, with the objects as *Map
+// if v is a *any.
+func decodeJSON(r io.Reader, v any) error {
+	d := json.NewDecoder(r)
+	p, ok := v.(*any)
+	if !ok {
+		return d.Decode(v)
+	}
+	// The tokens of a value that is known to be valid are read again, so that errors
+	// are reported by Decode, as for other targets.
+	var raw json.RawMessage
+	if err := d.Decode(&raw); err != nil {
+		return err
+	}
+	x, err := jsonValue(json.NewDecoder(bytes.NewReader(raw)))
+	if err != nil {
+		return err
+	}
+	*p = x
+	return nil
+}
+
+// jsonValue reads the next value of d.
+func jsonValue(d *json.Decoder) (any, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := NewMap(0)
+		for d.More() {
+			key, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := jsonValue(d)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key.(string), v)
+		}
+		return m, closeJSON(d)
+	case json.Delim('['):
+		s := []any{}
+		for d.More() {
+			v, err := jsonValue(d)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+		}
+		return s, closeJSON(d)
+	default:
+		return tok, nil
+	}
+}
+
+// closeJSON reads the delimiter that closes an object or array.
+func closeJSON(d *json.Decoder) error {
+	_, err := d.Token()
+	return err
+}
@@ -0,0 +1,141 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Map is a map with string keys that remembers the order in which its keys were first
+// set. Decoding into an interface value produces a *Map for every map of the input, in
+// the order of the input, and encoders write the keys of a *Map in that order, so that
+// converting between formats keeps the layout of the data.
+//
+// Templates cannot index a *Map or range over its keys, so the values that templates
+// are executed with are converted to plain maps with [Plain]. The order is therefore
+// kept only by conversions between formats: templates range over maps in sorted key
+// order, as for any Go map, rather than in the order of the document.
+type Map struct {
+	keys   []string
+	values map[string]any
+}
+
+// NewMap returns an empty map with room for n keys.
+func NewMap(n int) *Map {
+	return &Map{keys: make([]string, 0, n), values: make(map[string]any, n)}
+}
+
+// Len returns the number of keys of m.
+func (m *Map) Len() int { return len(m.keys) }
+
+// Keys returns the keys of m in order. The slice must not be modified.
+func (m *Map) Keys() []string { return m.keys }
+
+// Get returns the value of key, and whether m has key.
+func (m *Map) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set sets the value of key. A new key is added after the existing ones, and an
+// existing key keeps its position.
+func (m *Map) Set(key string, v any) {
+	if m.values == nil {
+		m.values = make(map[string]any)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// Delete removes key from m.
+func (m *Map) Delete(key string) {
+	if _, ok := m.values[key]; ok {
+		delete(m.values, key)
+		m.keys = slices.DeleteFunc(m.keys, func(k string) bool { return k == key })
+	}
+}
+
+// All returns an iterator over the keys and values of m in order.
+func (m *Map) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, k := range m.keys {
+			if !yield(k, m.values[k]) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON implements [json.Marshaler], writing the keys of m in order.
+func (m *Map) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalYAML implements [yaml.Marshaler], writing the keys of m in order.
+func (m *Map) MarshalYAML() (any, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, k := range m.keys {
+		key, value := new(yaml.Node), new(yaml.Node)
+		if err := key.Encode(k); err != nil {
+			return nil, err
+		}
+		if err := value.Encode(m.values[k]); err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, key, value)
+	}
+	return n, nil
+}
+
+// Plain returns v with every *Map converted to a map[string]any, recursively. Other
+// values are returned unchanged.
+func Plain(v any) any {
+	switch v := v.(type) {
+	case *Map:
+		m := make(map[string]any, v.Len())
+		for k, x := range v.All() {
+			m[k] = Plain(x)
+		}
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[k] = Plain(x)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, x := range v {
+			s[i] = Plain(x)
+		}
+		return s
+	case Documents:
+		return Documents(Plain([]any(v)).([]any))
+	default:
+		return v
+	}
+}
@@ -0,0 +1,114 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap(t *testing.T) {
+	m := NewMap(0)
+	m.Set("b", 1)
+	m.Set("a", 2)
+	m.Set("c", 3)
+	m.Set("b", 4)
+	assert.Equal(t, []string{"b", "a", "c"}, m.Keys(), "an existing key keeps its position")
+	v, ok := m.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 4, v)
+
+	m.Delete("a")
+	m.Delete("x")
+	assert.Equal(t, 2, m.Len())
+	assert.Equal(t, []string{"b", "c"}, slices.Collect(func(yield func(string) bool) {
+		for k := range m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}))
+
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":4,"c":3}`, string(b))
+	assert.Equal(t, map[string]any{"b": 4, "c": 3}, Plain(m))
+}
+
+func TestConvert_KeepsOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		from, to Format
+		expected string
+	}{
+		{
+			name:     "JSONToYAML",
+			data:     `{"z": 1, "a": {"x": [{"d": 1, "c": 2}], "b": null}}`,
+			from:     FormatJSON,
+			to:       FormatYAML,
+			expected: "z: 1\na:\n    x:\n        - d: 1\n          c: 2\n    b: null\n",
+		},
+		{
+			name:     "YAMLToJSON",
+			data:     "z: 1\na: &a\n  y: 2\n  b: 3\nm:\n  <<: *a\n  k: 4\n",
+			from:     FormatYAML,
+			to:       FormatJSON,
+			expected: `{"z":1,"a":{"y":2,"b":3},"m":{"y":2,"b":3,"k":4}}` + "\n",
+		},
+		{
+			name:     "TOMLToJSON",
+			data:     "z = 1\n[s]\ny = 2\nb = 3\n[a]\nx = 4\n",
+			from:     FormatTOML,
+			to:       FormatJSON,
+			expected: `{"z":1,"s":{"y":2,"b":3},"a":{"x":4}}` + "\n",
+		},
+		{
+			name:     "JSONToTOML",
+			data:     `{"z": "1", "s": {"y": true, "b": "3"}, "a": "4"}`,
+			from:     FormatJSON,
+			to:       FormatTOML,
+			expected: "z = \"1\"\na = \"4\"\n\n[s]\ny = true\nb = \"3\"\n",
+		},
+		{
+			name:     "JSONToTOMLEmptyKey",
+			data:     `{"z": "1", "": "2", "t": {"": true}}`,
+			from:     FormatJSON,
+			to:       FormatTOML,
+			expected: "\"\" = \"2\"\nz = \"1\"\n\n[t]\n\"\" = true\n",
+		},
+		{
+			name:     "JSONToXML",
+			data:     `{"doc": {"@z": "1", "@a": "2", "y": "3", "b": "4"}}`,
+			from:     FormatJSON,
+			to:       FormatXML,
+			expected: `<doc z="1" a="2"><y>3</y><b>4</b></doc>` + "\n",
+		},
+		{
+			name:     "XMLToJSON",
+			data:     `<doc z="1"><y>3</y><b>4</b></doc>`,
+			from:     FormatXML,
+			to:       FormatJSON,
+			expected: `{"doc":{"@z":"1","y":"3","b":"4"}}` + "\n",
+		},
+		{
+			name:     "CSVToJSON",
+			data:     "z,a\n1,2\n",
+			from:     FormatCSV,
+			to:       FormatJSON,
+			expected: `[{"z":"1","a":"2"}]` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			require.NoError(t, NewDecoder(bytes.NewReader([]byte(tt.data)), tt.from, nil).Decode(&v))
+			var buf bytes.Buffer
+			require.NoError(t, NewEncoder(&buf, tt.to, &EncoderOptions{NoIndent: true}).Encode(v))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
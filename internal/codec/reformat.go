@@ -0,0 +1,200 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CanReformat reports whether documents in format can be rewritten with [Reformat].
+func CanReformat(format Format) bool {
+	return format == FormatYAML || format == FormatTOML
+}
+
+// Reformat reads a document in format from r and writes it to w indented as set by
+// options, keeping what decoding into a generic value loses:
+//
+//   - YAML is rewritten from its nodes, keeping comments, anchors and aliases, key
+//     order, tags and the styles of scalars.
+//   - TOML is reindented line by line, as [Encoder] indents it, keeping comments and
+//     the keys and values as written.
+//
+// If the underlying decoder reports where in the input decoding failed, the returned
+// error is a [*DecodeError].
+func Reformat(w io.Writer, r io.Reader, format Format, options *EncoderOptions) error {
+	if options == nil {
+		options = &EncoderOptions{}
+	}
+	options.init()
+	var err error
+	switch format {
+	case FormatYAML:
+		err = reformatYAML(w, r, options.IndentSize)
+	case FormatTOML:
+		err = reformatTOML(w, r, options.indent())
+	default:
+		return fmt.Errorf("cannot reformat format: %s", format)
+	}
+	if err != nil {
+		return locateError(err, format)
+	}
+	return nil
+}
+
+// reformatYAML rewrites the documents of the YAML stream read from r to w, indented
+// with indent spaces.
+func reformatYAML(w io.Writer, r io.Reader, indent int) error {
+	d := yaml.NewDecoder(r)
+	var nodes []*yaml.Node
+	for {
+		n := new(yaml.Node)
+		err := d.Decode(n)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 0 {
+		return io.EOF
+	}
+
+	e := yaml.NewEncoder(w)
+	e.SetIndent(indent)
+	for _, n := range nodes {
+		untagMerges(n)
+		if err := e.Encode(n); err != nil {
+			return err
+		}
+	}
+	return e.Close()
+}
+
+// untagMerges clears the tag of the merge keys under n, which the encoder would
+// otherwise write out as "!!merge <<".
+func untagMerges(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!merge" && n.Style == 0 {
+		n.Tag = ""
+	}
+	for _, c := range n.Content {
+		untagMerges(c)
+	}
+}
+
+// reformatTOML rewrites the TOML document read from r to w, indenting the keys of each
+// table and the headers of nested tables with indent per level. The continuation lines
+// of arrays are indented one level further, and multi-line strings are left alone.
+func reformatTOML(w io.Writer, r io.Reader, indent string) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var v any
+	if err := decodeTOML(bytes.NewReader(src), &v); err != nil {
+		return err
+	}
+
+	var (
+		buf   bytes.Buffer
+		s     tomlScanner
+		level int // Nesting of the current table; 0 for the root table.
+	)
+	for i, line := range strings.Split(string(src), "\n") {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		if s.str != "" {
+			buf.WriteString(line)
+			s.scan(line)
+			continue
+		}
+		text := strings.TrimLeft(line, " \t")
+		n := level
+		switch {
+		case text == "" || text == "\r":
+		case s.depth > 0:
+			if text[0] != ']' && text[0] != '}' {
+				n++
+			}
+		case text[0] == '[':
+			level = tomlHeaderLevel(text)
+			n = level - 1
+		}
+		if text != "" && text != "\r" {
+			buf.WriteString(strings.Repeat(indent, n))
+		}
+		buf.WriteString(text)
+		s.scan(text)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// tomlScanner tracks the multi-line strings and brackets left open by the lines of a
+// TOML document.
+type tomlScanner struct {
+	str   string // Delimiter of the open multi-line string, if any.
+	depth int    // Number of open arrays and inline tables.
+}
+
+// scan advances s past line.
+func (s *tomlScanner) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		if s.str != "" {
+			switch {
+			case s.str == `"""` && line[i] == '\\':
+				i++
+			case strings.HasPrefix(line[i:], s.str):
+				i += len(s.str) - 1
+				s.str = ""
+			}
+			continue
+		}
+		switch c := line[i]; c {
+		case '#':
+			return
+		case '"', '\'':
+			if delim := strings.Repeat(string(c), 3); strings.HasPrefix(line[i:], delim) {
+				s.str = delim
+				i += len(delim) - 1
+				continue
+			}
+			for i++; i < len(line) && line[i] != c; i++ {
+				if c == '"' && line[i] == '\\' {
+					i++
+				}
+			}
+		case '[', '{':
+			s.depth++
+		case ']', '}':
+			s.depth--
+		}
+	}
+}
+
+// tomlHeaderLevel returns the number of keys in the name of the table header that
+// starts text, as in 2 for "[a.b]" or "[[a.'b.c']]".
+func tomlHeaderLevel(text string) int {
+	level := 1
+	for i := strings.IndexFunc(text, func(r rune) bool { return r != '[' }); i >= 0 && i < len(text); i++ {
+		switch c := text[i]; c {
+		case ']':
+			return level
+		case '.':
+			level++
+		case '"', '\'':
+			for i++; i < len(text) && text[i] != c; i++ {
+				if c == '"' && text[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return level
+}
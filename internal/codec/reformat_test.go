@@ -0,0 +1,128 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReformat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		data     string
+		options  *EncoderOptions
+		expected string
+	}{
+		{
+			name:   "YAMLComments",
+			format: FormatYAML,
+			data: `# Service settings.
+name: web # The name.
+ports:
+ - 80
+ - 443
+base: &base
+ replicas: 2
+prod:
+ <<: *base
+ # More replicas.
+ replicas: 4
+`,
+			options: &EncoderOptions{InsertSpaces: true, IndentSize: 4},
+			expected: `# Service settings.
+name: web # The name.
+ports:
+    - 80
+    - 443
+base: &base
+    replicas: 2
+prod:
+    <<: *base
+    # More replicas.
+    replicas: 4
+`,
+		},
+		{
+			name:     "YAMLScalarStyles",
+			format:   FormatYAML,
+			data:     "a: 'x'\nb: \"y\"\nc: 1.0\nd: 0x1F\ne: |\n  line\n",
+			expected: "a: 'x'\nb: \"y\"\nc: 1.0\nd: 0x1F\ne: |\n    line\n",
+		},
+		{
+			name:     "YAMLStream",
+			format:   FormatYAML,
+			data:     "a: 1 # One.\n---\nb: 2\n",
+			expected: "a: 1 # One.\n---\nb: 2\n",
+		},
+		{
+			name:   "TOML",
+			format: FormatTOML,
+			data: `# Settings.
+title = 'app' # Literal string.
+
+[server]
+host = "localhost"
+ports = [
+8080,
+  8081,
+]
+[server.tls]
+# Paths.
+"cert.file" = """
+  keep
+indentation"""
+
+[[users]]
+    name = "a"
+`,
+			options: &EncoderOptions{InsertSpaces: true, IndentSize: 2},
+			expected: `# Settings.
+title = 'app' # Literal string.
+
+[server]
+  host = "localhost"
+  ports = [
+    8080,
+    8081,
+  ]
+  [server.tls]
+    # Paths.
+    "cert.file" = """
+  keep
+indentation"""
+
+[[users]]
+  name = "a"
+`,
+		},
+		{
+			name:     "TOMLQuotedHeader",
+			format:   FormatTOML,
+			data:     "[a.\"b.c]\"]\nx = 1\n",
+			options:  &EncoderOptions{InsertSpaces: true, IndentSize: 1},
+			expected: " [a.\"b.c]\"]\n  x = 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Reformat(&buf, strings.NewReader(tt.data), tt.format, tt.options))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestReformat_Error(t *testing.T) {
+	var decodeErr *DecodeError
+	err := Reformat(new(bytes.Buffer), strings.NewReader("a = 1\nb =\n"), FormatTOML, nil)
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, 2, decodeErr.Locations[0].Line)
+
+	err = Reformat(new(bytes.Buffer), strings.NewReader("{}"), FormatJSON, nil)
+	require.Error(t, err)
+	assert.False(t, CanReformat(FormatJSON))
+}
@@ -0,0 +1,151 @@
+package codec
+
+import (
+	"cmp"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// decodeTOML reads a TOML document from r and stores it in v, with the tables as *Map
+// if v is a *any.
+func decodeTOML(r io.Reader, v any) error {
+	p, ok := v.(*any)
+	if !ok {
+		_, err := toml.NewDecoder(r).Decode(v)
+		return err
+	}
+	var m map[string]any
+	md, err := toml.NewDecoder(r).Decode(&m)
+	if err != nil {
+		return err
+	}
+	// The keys are listed in the order of the document, with the keys of the tables of
+	// an array of tables under the path of the array.
+	order := make(map[string]int)
+	for i, key := range md.Keys() {
+		path := strings.Join(key, tomlSep)
+		if _, ok := order[path]; !ok {
+			order[path] = i
+		}
+	}
+	*p = tomlValue(m, "", order)
+	return nil
+}
+
+// tomlValue returns v, the value at path, with the tables as *Map in the order of the
+// document. Keys missing from order, such as those of inline tables in arrays, follow
+// in sorted order.
+func tomlValue(v any, path string, order map[string]int) any {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			i, aok := order[tomlChild(path, a)]
+			j, bok := order[tomlChild(path, b)]
+			switch {
+			case aok && bok:
+				return cmp.Compare(i, j)
+			case aok != bok:
+				if aok {
+					return -1
+				}
+				return 1
+			default:
+				return strings.Compare(a, b)
+			}
+		})
+		m := NewMap(len(keys))
+		for _, k := range keys {
+			m.Set(k, tomlValue(v[k], tomlChild(path, k), order))
+		}
+		return m
+	case []map[string]any:
+		s := make([]any, len(v))
+		for i, x := range v {
+			s[i] = tomlValue(x, path, order)
+		}
+		return s
+	case []any:
+		s := make([]any, len(v))
+		for i, x := range v {
+			s[i] = tomlValue(x, path, order)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// tomlSep separates the keys of a path, which may contain dots.
+const tomlSep = "\x00"
+
+// tomlChild returns the path of key in the table at path.
+func tomlChild(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + tomlSep + key
+}
+
+// encodeTOML writes data to w as TOML, indented with indent.
+func encodeTOML(w io.Writer, data any, indent string) error {
+	e := toml.NewEncoder(w)
+	e.Indent = indent
+	return e.Encode(tomlOrdered(data))
+}
+
+// tomlOrdered returns v with every *Map converted to a struct with a field per key, in
+// order, as the encoder writes the keys of maps in sorted order but the fields of
+// structs in order. A *Map with a key that cannot be a field tag is converted to a map.
+func tomlOrdered(v any) any {
+	switch v := v.(type) {
+	case *Map:
+		fields := make([]reflect.StructField, 0, v.Len())
+		values := make([]any, 0, v.Len())
+		for k, x := range v.All() {
+			if k == "" || k == "-" || strings.Contains(k, ",") {
+				return tomlOrderedMap(v)
+			}
+			fields = append(fields, reflect.StructField{
+				Name: "F" + strconv.Itoa(len(fields)),
+				Type: reflect.TypeFor[any](),
+				Tag:  reflect.StructTag(`toml:` + strconv.Quote(k)),
+			})
+			values = append(values, tomlOrdered(x))
+		}
+		s := reflect.New(reflect.StructOf(fields)).Elem()
+		for i, x := range values {
+			if x != nil {
+				s.Field(i).Set(reflect.ValueOf(x))
+			}
+		}
+		return s.Interface()
+	case []any:
+		s := make([]any, len(v))
+		for i, x := range v {
+			s[i] = tomlOrdered(x)
+		}
+		return s
+	case Documents:
+		return tomlOrdered([]any(v))
+	default:
+		return v
+	}
+}
+
+// tomlOrderedMap returns m as a map, with its values converted by tomlOrdered.
+func tomlOrderedMap(m *Map) map[string]any {
+	plain := make(map[string]any, m.Len())
+	for k, x := range m.All() {
+		plain[k] = tomlOrdered(x)
+	}
+	return plain
+}
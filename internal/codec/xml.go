@@ -0,0 +1,264 @@
+package codec
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// XML has no data model of its own, so XML documents are mapped to and from the
+// generic values of the other formats as follows:
+//
+//   - A document is a map with a single key, the name of its root element.
+//   - An element with neither attributes nor child elements is its text, a string.
+//   - Any other element is a map. Attributes are keys prefixed with "@", child
+//     elements are keys named after them, and text is the key "#text".
+//   - Repeated child elements with the same name are an array.
+//   - Names keep their namespace prefixes, as in "soap:Envelope", and namespace
+//     declarations are attributes, as in "@xmlns:soap".
+//
+// Text is trimmed of surrounding white space, and text consisting only of white space
+// is dropped. All values decode as strings. Comments, processing instructions and
+// directives are ignored.
+//
+// When encoding, a map with a single key that is not an array is the document; any
+// other value is wrapped in an element named [XMLRootName], with the items of an array
+// as elements named [XMLItemName]. The keys of a [*Map] are encoded in order, and those
+// of other maps in sorted order. Scalars are formatted with %v, or RFC 3339 for times.
+const (
+	XMLAttrPrefix = "@"     // Prefix of the keys of attributes.
+	XMLTextKey    = "#text" // Key of the text of an element with attributes or children.
+	XMLRootName   = "root"  // Name of the root element wrapping any other value.
+	XMLItemName   = "item"  // Name of the elements of the items of a wrapped array.
+)
+
+// xmlElement is an element being decoded.
+type xmlElement struct {
+	name     string
+	value    *Map
+	text     strings.Builder
+	children bool
+}
+
+// decodeXML reads an XML document from r and stores its generic value in v.
+func decodeXML(r io.Reader, v any) error {
+	d := xml.NewDecoder(r)
+	var (
+		stack []*xmlElement
+		doc   *Map
+	)
+	for {
+		// RawToken keeps the namespace prefixes as written, but leaves it to us to
+		// check that elements are balanced.
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			if len(stack) > 0 {
+				line, _ := d.InputPos()
+				return &xml.SyntaxError{Msg: "unexpected EOF", Line: line}
+			}
+			if doc == nil {
+				return io.EOF
+			}
+			return setValue(v, doc)
+		}
+		if err != nil {
+			return err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if doc != nil && len(stack) == 0 {
+				line, _ := d.InputPos()
+				return &xml.SyntaxError{Msg: "multiple root elements", Line: line}
+			}
+			e := &xmlElement{name: xmlName(tok.Name), value: NewMap(len(tok.Attr))}
+			for _, attr := range tok.Attr {
+				e.value.Set(XMLAttrPrefix+xmlName(attr.Name), attr.Value)
+			}
+			if len(stack) > 0 {
+				stack[len(stack)-1].children = true
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != xmlName(tok.Name) {
+				line, _ := d.InputPos()
+				return &xml.SyntaxError{Msg: "unexpected end element </" + xmlName(tok.Name) + ">", Line: line}
+			}
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				doc = NewMap(1)
+				doc.Set(e.name, e.result())
+			} else {
+				appendXML(stack[len(stack)-1].value, e.name, e.result())
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			} else if len(strings.TrimSpace(string(tok))) > 0 {
+				line, _ := d.InputPos()
+				return &xml.SyntaxError{Msg: "text outside the root element", Line: line}
+			}
+		case xml.Comment, xml.ProcInst, xml.Directive:
+			// Not part of the data.
+		}
+	}
+}
+
+// result returns the value of the element e.
+func (e *xmlElement) result() any {
+	text := strings.TrimSpace(e.text.String())
+	if e.value.Len() == 0 && !e.children {
+		return text
+	}
+	if text != "" {
+		e.value.Set(XMLTextKey, text)
+	}
+	return e.value
+}
+
+// appendXML adds the child element name with value v to m, collecting repeated
+// elements in an array.
+func appendXML(m *Map, name string, v any) {
+	prev, ok := m.Get(name)
+	switch s, isArray := prev.([]any); {
+	case !ok:
+		m.Set(name, v)
+	case isArray:
+		m.Set(name, append(s, v))
+	default:
+		m.Set(name, []any{prev, v})
+	}
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// encodeXML writes data to w as an XML document, indented with indent.
+func encodeXML(w io.Writer, data any, indent string) error {
+	data, err := genericValue(data)
+	if err != nil {
+		return err
+	}
+	name, value := XMLRootName, data
+	if items, ok := data.([]any); ok {
+		m := NewMap(1)
+		m.Set(XMLItemName, items)
+		value = m
+	}
+	if m, ok := data.(*Map); ok && m.Len() == 1 {
+		k := m.Keys()[0]
+		if v, _ := m.Get(k); !isArray(v) {
+			name, value = k, v
+		}
+	}
+
+	e := xml.NewEncoder(w)
+	e.Indent("", indent)
+	if err := encodeXMLElement(e, name, value); err != nil {
+		return err
+	}
+	if err := e.Close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// encodeXMLElement writes the element name with the generic value v.
+func encodeXMLElement(e *xml.Encoder, name string, v any) error {
+	if !isXMLName(name) {
+		return fmt.Errorf("xml: invalid element name %q", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	m, isMap := v.(*Map)
+	if !isMap {
+		if isArray(v) {
+			return fmt.Errorf("xml: cannot encode nested array in element %q", name)
+		}
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		if v != nil {
+			if err := e.EncodeToken(xml.CharData(xmlScalar(v))); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	}
+
+	for k, x := range m.All() {
+		attr, ok := strings.CutPrefix(k, XMLAttrPrefix)
+		if !ok {
+			continue
+		}
+		if !isXMLName(attr) {
+			return fmt.Errorf("xml: invalid attribute name %q", attr)
+		}
+		switch x.(type) {
+		case *Map, []any:
+			return fmt.Errorf("xml: attribute %q must be a scalar", attr)
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr}, Value: xmlScalar(x)})
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if text, ok := m.Get(XMLTextKey); ok && text != nil {
+		if err := e.EncodeToken(xml.CharData(xmlScalar(text))); err != nil {
+			return err
+		}
+	}
+	for k, x := range m.All() {
+		if k == XMLTextKey || strings.HasPrefix(k, XMLAttrPrefix) {
+			continue
+		}
+		items, ok := x.([]any)
+		if !ok {
+			items = []any{x}
+		}
+		for _, item := range items {
+			if err := encodeXMLElement(e, k, item); err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func isArray(v any) bool {
+	_, ok := v.([]any)
+	return ok
+}
+
+// xmlScalar formats the scalar v as text.
+func xmlScalar(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// isXMLName reports whether s is a valid XML name, allowing a namespace prefix.
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r), r == '_', r == ':':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
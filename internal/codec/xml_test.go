@@ -0,0 +1,187 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_Decode_XML(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected any
+		errorMsg string
+	}{
+		{
+			name: "Mapping",
+			data: `<?xml version="1.0"?>
+<!-- fixture -->
+<config version="2">
+  <name>app</name>
+  <port>8080</port>
+  <host>a</host>
+  <host>b</host>
+  <empty/>
+  <note lang="en">hello</note>
+  <mixed>text <b>bold</b></mixed>
+</config>`,
+			expected: map[string]any{"config": map[string]any{
+				"@version": "2",
+				"name":     "app",
+				"port":     "8080",
+				"host":     []any{"a", "b"},
+				"empty":    "",
+				"note":     map[string]any{"@lang": "en", "#text": "hello"},
+				"mixed":    map[string]any{"#text": "text", "b": "bold"},
+			}},
+		},
+		{
+			name: "Namespaces",
+			data: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><m:Ping xmlns:m="urn:m"/></soap:Body></soap:Envelope>`,
+			expected: map[string]any{"soap:Envelope": map[string]any{
+				"@xmlns:soap": "http://schemas.xmlsoap.org/soap/envelope/",
+				"soap:Body": map[string]any{
+					"m:Ping": map[string]any{"@xmlns:m": "urn:m"},
+				},
+			}},
+		},
+		{
+			name:     "CDATA",
+			data:     `<a><![CDATA[<b> & c]]></a>`,
+			expected: map[string]any{"a": "<b> & c"},
+		},
+		{
+			name:     "MultipleRoots",
+			data:     `<a/><b/>`,
+			errorMsg: "multiple root elements",
+		},
+		{
+			name:     "Unclosed",
+			data:     `<a><b></b>`,
+			errorMsg: "unexpected EOF",
+		},
+		{
+			name:     "TextOutsideRoot",
+			data:     `<a/>b`,
+			errorMsg: "text outside the root element",
+		},
+		{
+			name:     "Empty",
+			data:     ` `,
+			errorMsg: "EOF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			err := NewDecoder(bytes.NewReader([]byte(tt.data)), FormatXML, nil).Decode(&v)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, Plain(v))
+		})
+	}
+}
+
+func TestEncoder_Encode_XML(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     any
+		options  *EncoderOptions
+		expected string
+		errorMsg string
+	}{
+		{
+			name: "Mapping",
+			data: map[string]any{"config": map[string]any{
+				"@version": 2,
+				"name":     "app",
+				"host":     []any{"a", "b"},
+				"note":     map[string]any{"@lang": "en", "#text": "hello"},
+				"empty":    nil,
+			}},
+			options: &EncoderOptions{InsertSpaces: true, IndentSize: 2},
+			expected: `<config version="2">
+  <empty></empty>
+  <host>a</host>
+  <host>b</host>
+  <name>app</name>
+  <note lang="en">hello</note>
+</config>
+`,
+		},
+		{
+			name:     "Root",
+			data:     map[string]any{"a": 1, "b": true},
+			options:  &EncoderOptions{NoIndent: true},
+			expected: "<root><a>1</a><b>true</b></root>\n",
+		},
+		{
+			name:     "RootArray",
+			data:     []any{1, 2},
+			options:  &EncoderOptions{NoIndent: true},
+			expected: "<root><item>1</item><item>2</item></root>\n",
+		},
+		{
+			name:     "Tabs",
+			data:     map[string]any{"a": map[string]any{"b": "x & y"}},
+			expected: "<a>\n\t\t\t\t<b>x &amp; y</b>\n</a>\n",
+		},
+		{
+			name:     "Namespaces",
+			data:     map[string]any{"soap:Envelope": map[string]any{"@xmlns:soap": "urn:s", "soap:Body": ""}},
+			options:  &EncoderOptions{NoIndent: true},
+			expected: `<soap:Envelope xmlns:soap="urn:s"><soap:Body></soap:Body></soap:Envelope>` + "\n",
+		},
+		{
+			name:     "Generic",
+			data:     map[any]any{"t": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "s": struct{ N int }{1}},
+			options:  &EncoderOptions{NoIndent: true},
+			expected: "<root><s><N>1</N></s><t>2024-01-02T03:04:05Z</t></root>\n",
+		},
+		{
+			name:     "InvalidName",
+			data:     map[string]any{"a b": 1},
+			errorMsg: `invalid element name "a b"`,
+		},
+		{
+			name:     "NestedArray",
+			data:     map[string]any{"a": []any{[]any{1}}},
+			errorMsg: "cannot encode nested array",
+		},
+		{
+			name:     "AttributeNotScalar",
+			data:     map[string]any{"a": map[string]any{"@b": []any{1}}},
+			errorMsg: `attribute "b" must be a scalar`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := NewEncoder(&buf, FormatXML, tt.options).Encode(tt.data)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestXML_roundTrip(t *testing.T) {
+	const doc = `<a x="1"><b>2</b><b>3</b><c y="4">5</c></a>` + "\n"
+	var v any
+	require.NoError(t, NewDecoder(bytes.NewReader([]byte(doc)), FormatXML, nil).Decode(&v))
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf, FormatXML, &EncoderOptions{NoIndent: true}).Encode(v))
+	assert.Equal(t, doc, buf.String())
+}
@@ -0,0 +1,188 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Documents holds the documents of a YAML stream of several documents, separated by
+// "---". A stream of a single document decodes as that document, and Documents
+// encode in YAML as a stream again.
+type Documents []any
+
+// decodeYAML reads the documents of a YAML stream from r and stores them in v, as
+// [Documents] if there are several, with the mappings as *Map if v is a *any.
+func decodeYAML(r io.Reader, v any) error {
+	d := yaml.NewDecoder(r)
+	var nodes []*yaml.Node
+	for {
+		n := new(yaml.Node)
+		err := d.Decode(n)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, n)
+	}
+
+	if len(nodes) == 0 {
+		return io.EOF
+	}
+	if _, ok := v.(*any); !ok && len(nodes) == 1 {
+		return nodes[0].Decode(v)
+	}
+	docs := make(Documents, len(nodes))
+	for i, n := range nodes {
+		// Decoding the node first applies the limits of yaml.v3 to self-referential
+		// anchors and excessive aliasing, before the aliases are expanded below.
+		if err := n.Decode(new(any)); err != nil {
+			return err
+		}
+		x, err := (&yamlConverter{expanding: make(map[*yaml.Node]bool)}).value(n)
+		if err != nil {
+			return err
+		}
+		docs[i] = x
+	}
+	if len(docs) == 1 {
+		return setValue(v, docs[0])
+	}
+	return setValue(v, docs)
+}
+
+// yamlConverter converts YAML nodes to generic values.
+type yamlConverter struct {
+	expanding map[*yaml.Node]bool // Anchored nodes whose aliases are being expanded.
+}
+
+// alias returns the value of the alias node n, or an error if n refers to a node that
+// contains it.
+func (c *yamlConverter) alias(n *yaml.Node) (*yaml.Node, func(), error) {
+	if c.expanding[n.Alias] {
+		return nil, nil, fmt.Errorf("yaml: line %d: anchor '%s' value contains itself", n.Line, n.Value)
+	}
+	c.expanding[n.Alias] = true
+	return n.Alias, func() { delete(c.expanding, n.Alias) }, nil
+}
+
+// value returns the value of n, with the mappings as *Map. Keys that are not strings
+// are formatted with %v.
+func (c *yamlConverter) value(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return c.value(n.Content[0])
+	case yaml.AliasNode:
+		target, done, err := c.alias(n)
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+		return c.value(target)
+	case yaml.SequenceNode:
+		s := make([]any, len(n.Content))
+		for i, cn := range n.Content {
+			x, err := c.value(cn)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = x
+		}
+		return s, nil
+	case yaml.MappingNode:
+		m := NewMap(len(n.Content) / 2)
+		if err := c.mapping(m, n); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case yaml.ScalarNode:
+		var x any
+		err := n.Decode(&x)
+		return x, err
+	default:
+		return nil, fmt.Errorf("yaml: unexpected node kind %d", n.Kind)
+	}
+}
+
+// mapping sets the entries of the mapping node n in m. Entries merged with the "<<"
+// key are set first, so that the explicit entries of n override them.
+func (c *yamlConverter) mapping(m *Map, n *yaml.Node) error {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Kind != yaml.ScalarNode || key.Tag != "!!merge" {
+			continue
+		}
+		merged := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			merged = value.Content
+		}
+		for _, mn := range merged {
+			if err := c.merge(m, mn, value.Line); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Kind == yaml.ScalarNode && key.Tag == "!!merge" {
+			continue
+		}
+		k, err := c.value(key)
+		if err != nil {
+			return err
+		}
+		x, err := c.value(value)
+		if err != nil {
+			return err
+		}
+		name, ok := k.(string)
+		if !ok {
+			name = fmt.Sprint(k)
+		}
+		m.Set(name, x)
+	}
+	return nil
+}
+
+// merge sets the entries of the mapping node n, merged at line, in m.
+func (c *yamlConverter) merge(m *Map, n *yaml.Node, line int) error {
+	if n.Kind == yaml.AliasNode {
+		target, done, err := c.alias(n)
+		if err != nil {
+			return err
+		}
+		defer done()
+		n = target
+	}
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("yaml: line %d: map merge requires map or sequence of maps as the value", line)
+	}
+	return c.mapping(m, n)
+}
+
+// encodeYAML writes data to w as YAML, as a stream of documents if data is [Documents],
+// or an array and options.YAMLStream is set.
+func encodeYAML(w io.Writer, data any, options *EncoderOptions) error {
+	e := yaml.NewEncoder(w)
+	e.SetIndent(options.IndentSize)
+	docs, ok := data.(Documents)
+	if !ok && options.YAMLStream {
+		docs, ok = data.([]any)
+	}
+	if !ok {
+		return e.Encode(data)
+	}
+	for _, doc := range docs {
+		if err := e.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return e.Close()
+}
@@ -0,0 +1,137 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder_Decode_YAMLStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected any
+	}{
+		{
+			name:     "Single",
+			data:     "---\na: 1\n",
+			expected: map[string]any{"a": 1},
+		},
+		{
+			name:     "SingleList",
+			data:     "- 1\n- 2\n",
+			expected: []any{1, 2},
+		},
+		{
+			name: "Stream",
+			data: "kind: Service\n---\nkind: Deployment\n---\n- 1\n",
+			expected: Documents{
+				map[string]any{"kind": "Service"},
+				map[string]any{"kind": "Deployment"},
+				[]any{1},
+			},
+		},
+		{
+			name:     "TrailingSeparator",
+			data:     "a: 1\n---\nb: 2\n---\n",
+			expected: Documents{map[string]any{"a": 1}, map[string]any{"b": 2}, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			require.NoError(t, NewDecoder(bytes.NewReader([]byte(tt.data)), FormatYAML, nil).Decode(&v))
+			assert.Equal(t, tt.expected, Plain(v))
+		})
+	}
+}
+
+func TestDecoder_Decode_YAMLStreamError(t *testing.T) {
+	var v any
+	err := NewDecoder(bytes.NewReader([]byte("a: 1\n---\nb: 2\n c: 3\n")), FormatYAML, nil).Decode(&v)
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, 4, decodeErr.Locations[0].Line, "lines count from the start of the stream")
+
+	var m map[string]any
+	err = NewDecoder(bytes.NewReader([]byte("a: 1\n---\nb: 2\n")), FormatYAML, nil).Decode(&m)
+	require.Error(t, err, "a stream does not decode into a map")
+}
+
+func TestEncoder_Encode_YAMLStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		data     any
+		options  *EncoderOptions
+		expected string
+	}{
+		{
+			name:     "Documents",
+			format:   FormatYAML,
+			data:     Documents{map[string]any{"a": 1}, []any{2}},
+			expected: "a: 1\n---\n- 2\n",
+		},
+		{
+			name:     "Array",
+			format:   FormatYAML,
+			data:     []any{map[string]any{"a": 1}, "b"},
+			expected: "- a: 1\n- b\n",
+		},
+		{
+			name:     "ArrayStream",
+			format:   FormatYAML,
+			data:     []any{map[string]any{"a": 1}, "b"},
+			options:  &EncoderOptions{YAMLStream: true},
+			expected: "a: 1\n---\nb\n",
+		},
+		{
+			name:     "JSON",
+			format:   FormatJSON,
+			data:     Documents{1, 2},
+			options:  &EncoderOptions{NoIndent: true},
+			expected: "[1,2]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, NewEncoder(&buf, tt.format, tt.options).Encode(tt.data))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestDecoder_Decode_YAMLAliases(t *testing.T) {
+	var bomb strings.Builder
+	bomb.WriteString("a0: &a0 [x, x, x, x, x, x, x, x, x, x]\n")
+	for i := 1; i < 10; i++ {
+		fmt.Fprintf(&bomb, "a%d: &a%d [*a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d, *a%d]\n", i, i,
+			i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1, i-1)
+	}
+	tests := []struct {
+		name     string
+		data     string
+		errorMsg string
+	}{
+		{"SelfReference", "a: &a\n  b: *a\n", "contains itself"},
+		{"SelfMerge", "a: &a\n  b: 1\n  c:\n    <<: *a\n", "contains itself"},
+		{"Bomb", bomb.String(), "excessive aliasing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			err := NewDecoder(strings.NewReader(tt.data), FormatYAML, nil).Decode(&v)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+
+	var v any
+	require.NoError(t, NewDecoder(strings.NewReader("a: &a [1]\nb: *a\nc: *a\n"), FormatYAML, nil).Decode(&v))
+	assert.Equal(t, map[string]any{"a": []any{1}, "b": []any{1}, "c": []any{1}}, Plain(v), "an anchor can be used repeatedly")
+}
@@ -0,0 +1,183 @@
+// Package diagnostic describes problems found while decoding data and parsing or
+// executing templates, in a form that lets editors place them at a source location.
+package diagnostic
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// Stage identifies the processing stage in which a problem was found.
+type Stage string
+
+// Processing stages.
+const (
+	StageDecode  Stage = "decode"
+	StageParse   Stage = "parse"
+	StageExecute Stage = "execute"
+	StageEncode  Stage = "encode"
+	StageCheck   Stage = "check" // Static checks of templates against data.
+)
+
+// Severity describes how serious a problem is.
+type Severity string
+
+// Severities.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic describes a single problem and where it was found.
+type Diagnostic struct {
+	Stage    Stage    `json:"stage"`
+	Severity Severity `json:"severity"`
+	Template string   `json:"template,omitempty"` // Name of the template source; empty for data.
+	Line     int      `json:"line"`               // 1-based line, or 0 if unknown.
+	Column   int      `json:"column"`             // 1-based column, or 0 if unknown.
+	Offset   int      `json:"offset"`             // 0-based byte offset, or -1 if unknown.
+	Code     string   `json:"code,omitempty"`     // Machine-readable error code, if any.
+	Message  string   `json:"message"`
+}
+
+// Sources holds the inputs that diagnostics refer to. It is used to fill in the
+// positions that an error does not report directly.
+type Sources struct {
+	Data      []byte     // The decoded data.
+	Templates tmpl.Files // Template sources, keyed by parse name.
+}
+
+// Error is an error that occurred in a specific processing stage.
+type Error struct {
+	Stage Stage
+	Msg   string // Context prepended to the message of Err.
+	Err   error
+}
+
+// Wrap returns an error that records stage and prefixes the message of err with msg.
+func Wrap(stage Stage, msg string, err error) error {
+	return &Error{Stage: stage, Msg: msg, Err: err}
+}
+
+func (e *Error) Error() string { return e.Msg + ": " + e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// templateErrorRe matches the location prefix that text/template adds to parse and
+// execution errors, as in "template: name:1:2: message". The column is only present
+// for execution errors.
+var templateErrorRe = regexp.MustCompile(`^(?:html/)?template: ?(.*?):(\d+):(?:(\d+):)? (?s:(.*))$`)
+
+// FromError returns the diagnostics describing err. If err does not record a
+// stage, a single diagnostic without a location is returned.
+func FromError(err error, src Sources) []Diagnostic {
+	var stageErr *Error
+	if !errors.As(err, &stageErr) {
+		return []Diagnostic{{Severity: SeverityError, Offset: -1, Message: err.Error()}}
+	}
+
+	base := Diagnostic{Stage: stageErr.Stage, Severity: SeverityError, Offset: -1}
+	cause := stageErr.Err
+
+	var (
+		decodeErr *codec.DecodeError
+		escapeErr *htmltemplate.Error
+		execErr   template.ExecError
+		limitErr  *tmpl.LimitError
+	)
+	switch {
+	case errors.As(cause, &decodeErr):
+		diags := make([]Diagnostic, 0, len(decodeErr.Locations))
+		for _, loc := range decodeErr.Locations {
+			d := base
+			d.Line, d.Column, d.Offset, d.Message = loc.Line, loc.Column, loc.Offset, loc.Message
+			diags = append(diags, d.resolve(src.Data))
+		}
+		return diags
+	case base.Stage == StageDecode && errors.Is(cause, io.ErrUnexpectedEOF):
+		base.Offset = len(src.Data)
+		base.Message = cause.Error()
+		return []Diagnostic{base.resolve(src.Data)}
+	case errors.As(cause, &escapeErr):
+		base.Code = tmpl.ErrorCodeName(escapeErr.ErrorCode)
+		base.Template, base.Line, base.Message = escapeErr.Name, escapeErr.Line, escapeErr.Description
+		if escapeErr.Node != nil {
+			loc, _ := (*parse.Tree)(nil).ErrorContext(escapeErr.Node)
+			base.parseLocation("template: " + loc + ": " + escapeErr.Description)
+		}
+	case errors.As(cause, &execErr):
+		base.parseLocation(execErr.Err.Error())
+		if errors.As(cause, &limitErr) {
+			base.Code, base.Message = limitErr.Code(), limitErr.Error()
+		}
+	case errors.As(cause, &limitErr):
+		// Output and time limits are reported by the writer, without a location.
+		base.Code, base.Message = limitErr.Code(), limitErr.Error()
+	default:
+		base.parseLocation(cause.Error())
+	}
+	return []Diagnostic{base.resolve(src.Templates[base.Template])}
+}
+
+// parseLocation sets the template, line, column and message of d from a message
+// carrying a text/template location prefix. If msg has no such prefix, it is used
+// as the message verbatim.
+func (d *Diagnostic) parseLocation(msg string) {
+	m := templateErrorRe.FindStringSubmatch(msg)
+	if m == nil {
+		d.Message = msg
+		return
+	}
+	d.Template, d.Message = m[1], m[4]
+	d.Line, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		// Template columns are 0-based byte offsets within the line.
+		col, _ := strconv.Atoi(m[3])
+		d.Column = col + 1
+	}
+}
+
+// resolve fills in whichever of the offset or line and column of d is missing,
+// using src. It returns the updated diagnostic.
+func (d Diagnostic) resolve(src []byte) Diagnostic {
+	switch {
+	case src == nil:
+	case d.Offset < 0 && d.Line > 0:
+		d.Offset = OffsetOf(src, d.Line, max(d.Column, 1))
+	case d.Offset >= 0 && d.Line == 0:
+		d.Line, d.Column = PositionOf(src, d.Offset)
+	}
+	return d
+}
+
+// OffsetOf returns the byte offset in src of the 1-based line and column,
+// or -1 if src has fewer lines.
+func OffsetOf(src []byte, line, column int) int {
+	offset := 0
+	for range line - 1 {
+		i := bytes.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return -1
+		}
+		offset += i + 1
+	}
+	return min(offset+column-1, len(src))
+}
+
+// PositionOf returns the 1-based line and column of the byte offset in src.
+func PositionOf(src []byte, offset int) (line, column int) {
+	offset = min(offset, len(src))
+	prefix := src[:offset]
+	line = 1 + bytes.Count(prefix, []byte{'\n'})
+	column = offset - bytes.LastIndexByte(prefix, '\n')
+	return line, column
+}
@@ -0,0 +1,164 @@
+package diagnostic
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"testing"
+	"text/template"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("boom")
+	err := Wrap(StageParse, "error parsing template", cause)
+	require.EqualError(t, err, "error parsing template: boom")
+	require.ErrorIs(t, err, cause)
+}
+
+func TestFromError(t *testing.T) {
+	src := "Hello,\n{{.Name true}}!"
+
+	t.Run("Parse", func(t *testing.T) {
+		_, err := template.New("page.tmpl").Parse("Hello,\n{{.Name")
+		require.Error(t, err)
+		got := FromError(Wrap(StageParse, "error parsing template", err), Sources{
+			Templates: tmpl.Files{"page.tmpl": []byte("Hello,\n{{.Name")},
+		})
+		assert.Equal(t, []Diagnostic{{
+			Stage:    StageParse,
+			Severity: SeverityError,
+			Template: "page.tmpl",
+			Line:     2,
+			Column:   0,
+			Offset:   7,
+			Message:  "unclosed action",
+		}}, got)
+	})
+
+	t.Run("Execute", func(t *testing.T) {
+		tpl := template.Must(template.New("page.tmpl").Parse(src))
+		err := tpl.Execute(io.Discard, map[string]string{"Name": "World"})
+		require.Error(t, err)
+		got := FromError(Wrap(StageExecute, "error executing template", err), Sources{
+			Templates: tmpl.Files{"page.tmpl": []byte(src)},
+		})
+		require.Len(t, got, 1)
+		assert.Equal(t, StageExecute, got[0].Stage)
+		assert.Equal(t, "page.tmpl", got[0].Template)
+		assert.Equal(t, 2, got[0].Line)
+		assert.Equal(t, 3, got[0].Column)
+		assert.Equal(t, 9, got[0].Offset)
+		assert.Contains(t, got[0].Message, `executing "page.tmpl" at <.Name>`)
+	})
+
+	t.Run("Escape", func(t *testing.T) {
+		tpl := htmltemplate.Must(htmltemplate.New("page.html").Parse(`<a href="{{if .}}/x{{else}}/y?{{end}}{{.}}">`))
+		err := tpl.Execute(io.Discard, "x")
+		require.Error(t, err)
+		got := FromError(Wrap(StageExecute, "error executing template", err), Sources{})
+		require.Len(t, got, 1)
+		assert.Equal(t, "ErrAmbigContext", got[0].Code)
+		assert.Equal(t, "page.html", got[0].Template)
+		assert.Equal(t, 1, got[0].Line)
+		assert.Equal(t, 40, got[0].Column)
+		assert.Equal(t, "{{.}} appears in an ambiguous context within a URL", got[0].Message)
+	})
+
+	t.Run("StepLimit", func(t *testing.T) {
+		files := tmpl.Files{"loop.tmpl": []byte("x\n  {{range 100}}{{end}}")}
+		set, err := tmpl.ParseFiles(files, tmpl.Options{}, nil)
+		require.NoError(t, err)
+		err = set.Execute(io.Discard, nil, tmpl.Limits{MaxSteps: 10})
+		got := FromError(Wrap(StageExecute, "error executing template", err), Sources{Templates: files})
+		assert.Equal(t, []Diagnostic{{
+			Stage:    StageExecute,
+			Severity: SeverityError,
+			Template: "loop.tmpl",
+			Line:     2,
+			Column:   11,
+			Offset:   12,
+			Code:     "ErrStepLimit",
+			Message:  "limit exceeded: execution took more than 10 steps",
+		}}, got)
+	})
+
+	t.Run("OutputLimit", func(t *testing.T) {
+		files := tmpl.Files{"big.tmpl": []byte("{{range 100}}abc{{end}}")}
+		set, err := tmpl.ParseFiles(files, tmpl.Options{}, nil)
+		require.NoError(t, err)
+		err = set.Execute(io.Discard, nil, tmpl.Limits{MaxOutputBytes: 10})
+		got := FromError(Wrap(StageExecute, "error executing template", err), Sources{Templates: files})
+		assert.Equal(t, []Diagnostic{{
+			Stage:    StageExecute,
+			Severity: SeverityError,
+			Offset:   -1,
+			Code:     "ErrOutputLimit",
+			Message:  "limit exceeded: output is larger than 10 bytes",
+		}}, got)
+	})
+
+	t.Run("DecodeJSON", func(t *testing.T) {
+		data := []byte("{\n\"a\": 1,\n}")
+		var v interface{}
+		err := codec.NewDecoder(bytes.NewReader(data), codec.FormatJSON, nil).Decode(&v)
+		got := FromError(Wrap(StageDecode, "error decoding context data", err), Sources{Data: data})
+		require.Len(t, got, 1)
+		assert.Equal(t, StageDecode, got[0].Stage)
+		assert.Empty(t, got[0].Template)
+		assert.Equal(t, 3, got[0].Line)
+		assert.Equal(t, 1, got[0].Column)
+		assert.Equal(t, 10, got[0].Offset)
+	})
+
+	t.Run("DecodeYAML", func(t *testing.T) {
+		data := []byte("a: 1\n b: 2\n")
+		var v interface{}
+		err := codec.NewDecoder(bytes.NewReader(data), codec.FormatYAML, nil).Decode(&v)
+		got := FromError(Wrap(StageDecode, "error decoding data from format yaml", err), Sources{Data: data})
+		require.Len(t, got, 1)
+		assert.Equal(t, 2, got[0].Line)
+		assert.Equal(t, 0, got[0].Column)
+		assert.Equal(t, 5, got[0].Offset)
+	})
+
+	t.Run("DecodeUnexpectedEOF", func(t *testing.T) {
+		data := []byte(`{"a": 1`)
+		var v interface{}
+		err := codec.NewDecoder(bytes.NewReader(data), codec.FormatJSON, nil).Decode(&v)
+		got := FromError(Wrap(StageDecode, "error decoding context data", err), Sources{Data: data})
+		require.Len(t, got, 1)
+		assert.Equal(t, 1, got[0].Line)
+		assert.Equal(t, 8, got[0].Column)
+		assert.Equal(t, 7, got[0].Offset)
+	})
+
+	t.Run("Unlocated", func(t *testing.T) {
+		got := FromError(Wrap(StageParse, "error selecting entry template", tmpl.ErrNoEntry), Sources{})
+		assert.Equal(t, []Diagnostic{{
+			Stage:    StageParse,
+			Severity: SeverityError,
+			Offset:   -1,
+			Message:  tmpl.ErrNoEntry.Error(),
+		}}, got)
+	})
+
+	t.Run("NoStage", func(t *testing.T) {
+		got := FromError(errors.New("boom"), Sources{})
+		assert.Equal(t, []Diagnostic{{Severity: SeverityError, Offset: -1, Message: "boom"}}, got)
+	})
+}
+
+func TestPositionOf(t *testing.T) {
+	src := []byte("ab\ncd\n")
+	for offset, want := range [][2]int{{1, 1}, {1, 2}, {1, 3}, {2, 1}, {2, 2}, {2, 3}, {3, 1}} {
+		line, column := PositionOf(src, offset)
+		assert.Equal(t, want, [2]int{line, column}, "offset %d", offset)
+		assert.Equal(t, offset, OffsetOf(src, line, column), "offset %d", offset)
+	}
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"github.com/bartventer/go-template-playground/internal/analysis"
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// AnalyzeRequest is a request to report the references in templates.
+type AnalyzeRequest struct {
+	Templates tmpl.Files
+	Options   tmpl.Options // Only the delimiters are used.
+}
+
+// Sources returns the sources that diagnostics for the request refer to.
+func (r *AnalyzeRequest) Sources() diagnostic.Sources {
+	return diagnostic.Sources{Templates: r.Templates}
+}
+
+// Analyze parses the templates of req and reports the references they contain.
+func (e *Engine) Analyze(req *AnalyzeRequest) (*analysis.Report, error) {
+	report, err := analysis.Analyze(req.Templates, req.Options)
+	if err != nil {
+		return nil, diagnostic.Wrap(diagnostic.StageParse, "error parsing template", err)
+	}
+	return report, nil
+}
+
+// CheckRequest is a request to check templates against context data.
+type CheckRequest struct {
+	Templates tmpl.Files
+	Data      []byte
+	Format    codec.Format
+	Options   tmpl.Options
+	Decoder   *codec.DecoderOptions // Options of decoding Data; nil selects the defaults.
+}
+
+// Sources returns the sources that diagnostics for the request refer to.
+func (r *CheckRequest) Sources() diagnostic.Sources {
+	return diagnostic.Sources{Data: r.Data, Templates: r.Templates}
+}
+
+// CheckResponse is the result of a [CheckRequest].
+type CheckResponse struct {
+	Diagnostics []diagnostic.Diagnostic // Warnings about the templates.
+}
+
+// Check decodes the context data of req and checks its entry template against it,
+// without executing it.
+func (e *Engine) Check(req *CheckRequest) (*CheckResponse, error) {
+	ctx, err := DecodeContext(req.Data, req.Format, req.Decoder)
+	if err != nil {
+		return nil, err
+	}
+	diags, err := analysis.Check(req.Templates, req.Options, ctx, e.funcs)
+	if err != nil {
+		return nil, diagnostic.Wrap(diagnostic.StageParse, "error checking template", err)
+	}
+	return &CheckResponse{Diagnostics: diags}, nil
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/analysis"
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Analyze(t *testing.T) {
+	e := New(0)
+	report, err := e.Analyze(&AnalyzeRequest{Templates: tmpl.Files{"a": []byte(`{{.Name}}`)}})
+	require.NoError(t, err)
+	assert.NotNil(t, report)
+
+	_, err = e.Analyze(&AnalyzeRequest{Templates: tmpl.Files{"a": []byte(`{{.Name`)}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error parsing template")
+}
+
+func TestEngine_Check(t *testing.T) {
+	e := New(0)
+	resp, err := e.Check(&CheckRequest{
+		Templates: tmpl.Files{"a": []byte(`{{.Name}} {{.Missing}}`)},
+		Data:      []byte(`{"Name": "World"}`),
+		Format:    "json",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Diagnostics, 1)
+	assert.Contains(t, resp.Diagnostics[0].Message, "Missing")
+
+	resp, err = e.Check(&CheckRequest{
+		Templates: tmpl.Files{"a": []byte(`{{range .}}{{.name}}{{end}}`)},
+		Data:      []byte("name;qty\napple;3\n"),
+		Format:    "csv",
+		Decoder:   &codec.DecoderOptions{CSV: codec.CSVOptions{Delimiter: ';'}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Diagnostics, "the data is decoded as when rendering")
+
+	resp, err = e.Check(&CheckRequest{
+		Templates: tmpl.Files{"a": []byte(`{{range .}}{{.apple}}{{end}}`)},
+		Data:      []byte("apple,3\npear,5\n"),
+		Format:    "csv",
+		Decoder:   &codec.DecoderOptions{CSV: codec.CSVOptions{NoHeader: true}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Diagnostics, 1, "records without a header are arrays")
+	assert.Equal(t, analysis.CodeScalarField, resp.Diagnostics[0].Code)
+
+	_, err = e.Check(&CheckRequest{Templates: tmpl.Files{"a": nil}, Data: []byte(`{`), Format: "json"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error decoding context data")
+}
@@ -0,0 +1,150 @@
+// Package engine implements the pipelines behind the playground: rendering templates
+// with context data, transforming data between formats, and analyzing and checking
+// templates. It has no platform dependencies, so that the WebAssembly module and
+// native front ends produce identical results.
+//
+// Errors returned by the pipelines record the stage at which they occurred, and can
+// be turned into diagnostics with [diagnostic.FromError] and the sources of the request.
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"slices"
+	"text/template"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// Engine runs the pipelines. It caches parsed templates, so that rendering the same
+// templates with changing data skips parsing, and is safe for concurrent use.
+type Engine struct {
+	cache  *tmpl.Cache
+	funcs  template.FuncMap // All functions available to templates.
+	custom template.FuncMap // Functions added with WithFuncs.
+}
+
+// Option configures an [Engine].
+type Option func(*Engine)
+
+// WithFuncs adds funcs to the functions available to templates. They take precedence
+// over the default functions of the same name.
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(e *Engine) {
+		e.funcs, e.custom = maps.Clone(e.funcs), maps.Clone(e.custom)
+		if e.custom == nil {
+			e.custom = make(template.FuncMap, len(funcs))
+		}
+		maps.Copy(e.funcs, funcs)
+		maps.Copy(e.custom, funcs)
+	}
+}
+
+// New returns an engine caching up to cacheSize parsed template sets. A size less
+// than one selects [tmpl.DefaultCacheSize].
+func New(cacheSize int, opts ...Option) *Engine {
+	e := &Engine{cache: tmpl.NewCache(cacheSize), funcs: tmpl.TemplateFuncs()}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// FuncNames returns the sorted names of the functions available to templates, including
+// those added with [WithFuncs].
+func (e *Engine) FuncNames() []string {
+	return slices.Sorted(maps.Keys(e.funcs))
+}
+
+// DecodeContext decodes data in format with options into a value to execute templates
+// with, whose maps are plain maps, so that templates can index them. Ranging over them
+// visits the keys in sorted order rather than in the order of data. Nil options select
+// the defaults.
+func DecodeContext(data []byte, format codec.Format, options *codec.DecoderOptions) (any, error) {
+	v, err := Decode(data, format, options)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Plain(v), nil
+}
+
+// Decode is like [DecodeContext], but keeps the maps of data as [*codec.Map], in the
+// order of data, for encoding in another format.
+func Decode(data []byte, format codec.Format, options *codec.DecoderOptions) (any, error) {
+	var v any
+	if err := codec.NewDecoder(bytes.NewReader(data), format, options).Decode(&v); err != nil {
+		return nil, diagnostic.Wrap(diagnostic.StageDecode, "error decoding context data", err)
+	}
+	return v, nil
+}
+
+// Execute parses files with options, unless they are cached, and executes the entry
+// template with data.
+func (e *Engine) Execute(files tmpl.Files, data any, options tmpl.Options) ([]byte, error) {
+	return e.ExecuteContext(context.Background(), files, data, options)
+}
+
+// ExecuteContext is like [Engine.Execute], but aborts execution once ctx is done.
+func (e *Engine) ExecuteContext(ctx context.Context, files tmpl.Files, data any, options tmpl.Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.ExecuteTo(ctx, &buf, files, data, options); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExecuteTo is like [Engine.ExecuteContext], but writes the output to w as it is
+// produced. If execution fails, w holds the output written before the error.
+func (e *Engine) ExecuteTo(ctx context.Context, w io.Writer, files tmpl.Files, data any, options tmpl.Options) error {
+	return e.executeTo(ctx, w, files, data, options, nil, nil)
+}
+
+// executeTo is like [Engine.ExecuteTo], and records the parse and execute durations in
+// m, unless it is nil, and the trace of the execution in trace, unless it is nil.
+func (e *Engine) executeTo(ctx context.Context, w io.Writer, files tmpl.Files, data any, options tmpl.Options, m *Metrics, trace *tmpl.Trace) error {
+	if trace != nil {
+		options.Trace = true
+	}
+	stop := m.track(diagnostic.StageParse)
+	set, err := e.cache.Parse(files, options, e.funcs)
+	if err != nil {
+		stop()
+		return diagnostic.Wrap(diagnostic.StageParse, "error parsing template", err)
+	}
+	if options.Deterministic != nil {
+		set.Funcs(options.Funcs())
+		if e.custom != nil {
+			set.Funcs(e.custom)
+		}
+	}
+	t, err := tmpl.Entry(set, files, options.Entry)
+	stop()
+	if err != nil {
+		return diagnostic.Wrap(diagnostic.StageParse, "error selecting entry template", err)
+	}
+
+	stop = m.track(diagnostic.StageExecute)
+	err = t.ExecuteTrace(ctx, w, data, options.Limits, trace)
+	stop()
+	if err != nil {
+		var (
+			escapeErr *htmltemplate.Error
+			limitErr  *tmpl.LimitError
+		)
+		switch {
+		case errors.As(err, &escapeErr):
+			return diagnostic.Wrap(diagnostic.StageExecute,
+				"error executing template: "+tmpl.ErrorCodeName(escapeErr.ErrorCode), err)
+		case errors.As(err, &limitErr):
+			return diagnostic.Wrap(diagnostic.StageExecute, "error executing template: "+limitErr.Code(), err)
+		}
+		return diagnostic.Wrap(diagnostic.StageExecute, "error executing template", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+)
+
+// Metrics describes the cost of a request. A request given a non-nil *Metrics fills
+// it in, even if the request fails. The durations of stages that did not run are zero.
+//
+// The allocation counts are taken from [runtime.ReadMemStats], which stops the world,
+// and include the allocations of other goroutines running at the same time.
+type Metrics struct {
+	Decode     time.Duration // Decoding the data.
+	Parse      time.Duration // Parsing the templates, or looking them up in the cache.
+	Execute    time.Duration // Executing the entry template.
+	Encode     time.Duration // Encoding the data.
+	BytesIn    int           // Size of the templates and data of the request.
+	BytesOut   int           // Size of the output.
+	Allocs     uint64        // Number of heap objects allocated.
+	AllocBytes uint64        // Number of heap bytes allocated.
+}
+
+// begin records the size of sources and the heap statistics at the start of a request,
+// and returns a function that records the allocations since. It does nothing if m is
+// nil.
+func (m *Metrics) begin(sources diagnostic.Sources) (end func()) {
+	if m == nil {
+		return func() {}
+	}
+	*m = Metrics{BytesIn: len(sources.Data)}
+	for _, src := range sources.Templates {
+		m.BytesIn += len(src)
+	}
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	return func() {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		m.Allocs = after.Mallocs - before.Mallocs
+		m.AllocBytes = after.TotalAlloc - before.TotalAlloc
+	}
+}
+
+// track returns a function that adds the time elapsed since the call to the duration
+// of stage. It does nothing if m is nil.
+func (m *Metrics) track(stage diagnostic.Stage) (stop func()) {
+	if m == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		switch stage {
+		case diagnostic.StageDecode:
+			m.Decode += elapsed
+		case diagnostic.StageParse:
+			m.Parse += elapsed
+		case diagnostic.StageExecute:
+			m.Execute += elapsed
+		case diagnostic.StageEncode:
+			m.Encode += elapsed
+		case diagnostic.StageCheck:
+			// Checks are not timed.
+		}
+	}
+}
+
+// writer returns w wrapped so that the bytes written to it are counted in BytesOut, or
+// w itself if m is nil.
+func (m *Metrics) writer(w io.Writer) io.Writer {
+	if m == nil {
+		return w
+	}
+	return &countingWriter{w: w, n: &m.BytesOut}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	*cw.n += n
+	return n, err
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_render(t *testing.T) {
+	var m Metrics
+	resp, err := New(0).Render(&RenderRequest{
+		Templates: tmpl.Files{"a": []byte(`{{range .}}{{.}}{{end}}`)},
+		Data:      []byte(`[1, 2, 3]`),
+		Format:    "json",
+		Metrics:   &m,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "123", string(resp.Output))
+	assert.Equal(t, 32, m.BytesIn)
+	assert.Equal(t, 3, m.BytesOut)
+	// Stages can take no measurable time on coarse clocks; the output shows that
+	// execution ran, and TestMetrics_track that a stage that runs is timed.
+	assert.GreaterOrEqual(t, m.Decode, time.Duration(0))
+	assert.GreaterOrEqual(t, m.Parse, time.Duration(0))
+	assert.GreaterOrEqual(t, m.Execute, time.Duration(0))
+	assert.Zero(t, m.Encode)
+	assert.Positive(t, m.Allocs)
+	assert.Positive(t, m.AllocBytes)
+}
+
+func TestMetrics_track(t *testing.T) {
+	var m Metrics
+	stop := m.track(diagnostic.StageExecute)
+	time.Sleep(time.Millisecond)
+	stop()
+	m.track(diagnostic.StageExecute)()
+	assert.GreaterOrEqual(t, m.Execute, time.Millisecond)
+	assert.Zero(t, m.Decode)
+	assert.Zero(t, m.Parse)
+	assert.Zero(t, m.Encode)
+
+	(*Metrics)(nil).track(diagnostic.StageDecode)()
+}
+
+func TestMetrics_renderError(t *testing.T) {
+	var m Metrics
+	_, err := New(0).Render(&RenderRequest{
+		Templates: tmpl.Files{"a": []byte(`{{.}}`)},
+		Data:      []byte(`{`),
+		Format:    "json",
+		Metrics:   &m,
+	})
+	require.Error(t, err)
+	assert.Equal(t, 6, m.BytesIn)
+	assert.GreaterOrEqual(t, m.Decode, time.Duration(0))
+	assert.Positive(t, m.Allocs)
+	assert.Zero(t, m.Parse)
+	assert.Zero(t, m.Execute)
+	assert.Zero(t, m.BytesOut)
+}
+
+func TestMetrics_transform(t *testing.T) {
+	var m Metrics
+	resp, err := New(0).Transform(&TransformRequest{
+		Data:    []byte(`{"a":1}`),
+		From:    "json",
+		To:      "yaml",
+		Metrics: &m,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7, m.BytesIn)
+	assert.Equal(t, len(resp.Output), m.BytesOut)
+	assert.Positive(t, m.BytesOut)
+	assert.GreaterOrEqual(t, m.Decode, time.Duration(0))
+	assert.GreaterOrEqual(t, m.Encode, time.Duration(0))
+	assert.Zero(t, m.Parse)
+	assert.Zero(t, m.Execute)
+}
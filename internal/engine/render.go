@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// RenderRequest is a request to execute templates with context data.
+type RenderRequest struct {
+	Templates tmpl.Files   // Template sources, by name.
+	Data      []byte       // Context data.
+	Format    codec.Format // Format of Data.
+	Options   tmpl.Options
+	Decoder   *codec.DecoderOptions // Options of decoding Data; nil selects the defaults.
+	Metrics   *Metrics              // Filled in with the metrics of the request, unless nil.
+	Trace     *tmpl.Trace           // Filled in with the trace of the execution, unless nil.
+}
+
+// Sources returns the sources that diagnostics for the request refer to.
+func (r *RenderRequest) Sources() diagnostic.Sources {
+	return diagnostic.Sources{Data: r.Data, Templates: r.Templates}
+}
+
+// RenderResponse is the result of a [RenderRequest].
+type RenderResponse struct {
+	Output []byte
+}
+
+// Render decodes the context data of req and executes its entry template.
+func (e *Engine) Render(req *RenderRequest) (*RenderResponse, error) {
+	return e.RenderContext(context.Background(), req)
+}
+
+// RenderContext is like [Engine.Render], but aborts execution once ctx is done.
+func (e *Engine) RenderContext(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	var buf bytes.Buffer
+	if err := e.RenderTo(ctx, &buf, req); err != nil {
+		return nil, err
+	}
+	return &RenderResponse{Output: buf.Bytes()}, nil
+}
+
+// RenderTo is like [Engine.RenderContext], but writes the output to w as it is
+// produced, rather than buffering it. If execution fails, w holds the output written
+// before the error.
+func (e *Engine) RenderTo(ctx context.Context, w io.Writer, req *RenderRequest) error {
+	m := req.Metrics
+	defer m.begin(req.Sources())()
+
+	stop := m.track(diagnostic.StageDecode)
+	data, err := DecodeContext(req.Data, req.Format, req.Decoder)
+	stop()
+	if err != nil {
+		return err
+	}
+	return e.executeTo(ctx, m.writer(w), req.Templates, data, req.Options, m, req.Trace)
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Render(t *testing.T) {
+	files := tmpl.Files{
+		"layout.tmpl": []byte(`<{{block "content" .}}default{{end}}>`),
+		"page.tmpl":   []byte(`{{define "content"}}{{.Name}}{{end}}{{template "layout.tmpl" .}}`),
+	}
+	data := []byte(`{"Name": "World"}`)
+
+	tests := []struct {
+		name     string
+		req      RenderRequest
+		expected string
+		errorMsg string
+	}{
+		{
+			name:     "Entry",
+			req:      RenderRequest{Templates: files, Data: data, Format: "json", Options: tmpl.Options{Entry: "page.tmpl"}},
+			expected: "<World>",
+		},
+		{
+			name:     "SingleFileDefaultEntry",
+			req:      RenderRequest{Templates: tmpl.Files{"a.tmpl": []byte("{{.Name}}")}, Data: data, Format: "json"},
+			expected: "World",
+		},
+		{
+			name: "Deterministic",
+			req: RenderRequest{
+				Templates: tmpl.Files{"a": []byte(`{{date}}`)},
+				Data:      []byte(`{}`),
+				Format:    "json",
+				Options: tmpl.Options{Deterministic: &tmpl.Determinism{
+					Now: time.Date(2024, 6, 30, 22, 0, 0, 0, time.UTC),
+				}},
+			},
+			expected: "2024-06-30",
+		},
+		{
+			name:     "NoEntry",
+			req:      RenderRequest{Templates: files, Data: data, Format: "json"},
+			errorMsg: "error selecting entry template: no entry template specified",
+		},
+		{
+			name:     "ParseError",
+			req:      RenderRequest{Templates: tmpl.Files{"a.tmpl": []byte("{{.Name")}, Data: data, Format: "json"},
+			errorMsg: "error parsing template: template: a.tmpl:1",
+		},
+		{
+			name:     "DecodeError",
+			req:      RenderRequest{Templates: files, Data: []byte(`{`), Format: "json"},
+			errorMsg: "error decoding context data",
+		},
+		{
+			name: "EscapeError",
+			req: RenderRequest{
+				Templates: tmpl.Files{"a": []byte(`<a href="{{.}}`)},
+				Data:      []byte(`"x"`),
+				Format:    "json",
+				Options:   tmpl.Options{Engine: tmpl.EngineHTML},
+			},
+			errorMsg: "error executing template: ErrEndContext",
+		},
+		{
+			name: "LimitError",
+			req: RenderRequest{
+				Templates: tmpl.Files{"a": []byte(`{{range 100}}{{end}}`)},
+				Data:      []byte(`{}`),
+				Format:    "json",
+				Options:   tmpl.Options{Limits: tmpl.Limits{MaxSteps: 10}},
+			},
+			errorMsg: "error executing template: ErrStepLimit",
+		},
+	}
+	e := New(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := e.Render(&tt.req)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(resp.Output))
+		})
+	}
+}
+
+func TestEngine_Render_diagnostics(t *testing.T) {
+	req := &RenderRequest{
+		Templates: tmpl.Files{"a": []byte("ok\n{{.nope}}")},
+		Data:      []byte(`{}`),
+		Format:    "json",
+		Options:   tmpl.Options{MissingKey: tmpl.MissingKeyError},
+	}
+	_, err := New(0).Render(req)
+	require.Error(t, err)
+
+	diags := diagnostic.FromError(err, req.Sources())
+	require.Len(t, diags, 1)
+	assert.Equal(t, diagnostic.StageExecute, diags[0].Stage)
+	assert.Equal(t, "a", diags[0].Template)
+	assert.Equal(t, 2, diags[0].Line)
+}
+
+func TestEngine_Execute_cache(t *testing.T) {
+	e := New(0)
+	files := tmpl.Files{"a": []byte(`{{define "b"}}b{{end}}{{template "b"}}`)}
+	for range 2 {
+		out, err := e.Execute(files, nil, tmpl.Options{})
+		require.NoError(t, err)
+		assert.Equal(t, "b", string(out))
+	}
+	assert.Equal(t, 1, e.cache.Len())
+
+	_, err := e.Execute(tmpl.Files{"a": []byte(`{{template "b"}}`)}, nil, tmpl.Options{})
+	require.Error(t, err, "definitions do not leak between template sets")
+}
+
+func TestEngine_WithFuncs(t *testing.T) {
+	e := New(0, WithFuncs(map[string]any{
+		"shout": func(s string) string { return s + "!" },
+		"upper": func(s string) string { return "UPPER " + s },
+	}))
+	for _, options := range []tmpl.Options{{}, {Deterministic: &tmpl.Determinism{}}} {
+		out, err := e.Execute(tmpl.Files{"a": []byte(`{{shout "hi"}} {{upper "x"}} {{lower "Y"}}`)}, nil, options)
+		require.NoError(t, err)
+		assert.Equal(t, "hi! UPPER x y", string(out))
+	}
+
+	_, err := New(0).Execute(tmpl.Files{"a": []byte(`{{shout "hi"}}`)}, nil, tmpl.Options{})
+	require.Error(t, err, "functions are not shared between engines")
+}
+
+func TestEngine_RenderContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := New(0).RenderContext(ctx, &RenderRequest{
+		Templates: tmpl.Files{"a": []byte(`a`)},
+		Data:      []byte(`{}`),
+		Format:    "json",
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "error executing template")
+}
+
+func TestEngine_RenderTo(t *testing.T) {
+	var buf bytes.Buffer
+	err := New(0).RenderTo(context.Background(), &buf, &RenderRequest{
+		Templates: tmpl.Files{"a": []byte(`{{range .}}{{.}}{{end}}{{.x}}`)},
+		Data:      []byte(`[1, 2]`),
+		Format:    "json",
+	})
+	require.Error(t, err)
+	assert.Equal(t, "12", buf.String(), "output before the error is written")
+	assert.Contains(t, err.Error(), "error executing template")
+}
+
+func TestEngine_Render_trace(t *testing.T) {
+	e := New(4)
+	trace := &tmpl.Trace{}
+	resp, err := e.Render(&RenderRequest{
+		Templates: tmpl.Files{"a": []byte(`{{range .}}{{.}}{{end}}`)},
+		Data:      []byte(`[1, 2]`),
+		Format:    "json",
+		Trace:     trace,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "12", string(resp.Output))
+	require.Len(t, trace.Events, 3)
+	assert.Equal(t, tmpl.TraceRange, trace.Events[0].Kind)
+	assert.Equal(t, "2", trace.Events[2].Value)
+
+	resp, err = e.Render(&RenderRequest{
+		Templates: tmpl.Files{"a": []byte(`{{range .}}{{.}}{{end}}`)},
+		Data:      []byte(`[1, 2]`),
+		Format:    "json",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "12", string(resp.Output), "untraced requests do not share the traced templates")
+}
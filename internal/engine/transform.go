@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"bytes"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+)
+
+// TransformRequest is a request to convert data from one format to another.
+type TransformRequest struct {
+	Data    []byte
+	From    codec.Format          // Format of Data.
+	To      codec.Format          // Format to convert to; defaults to From.
+	Options *codec.EncoderOptions // Its CSV settings also apply to decoding.
+	Metrics *Metrics              // Filled in with the metrics of the request, unless nil.
+}
+
+// Sources returns the sources that diagnostics for the request refer to.
+func (r *TransformRequest) Sources() diagnostic.Sources {
+	return diagnostic.Sources{Data: r.Data}
+}
+
+// TransformResponse is the result of a [TransformRequest].
+type TransformResponse struct {
+	Output []byte
+}
+
+// Transform decodes the data of req and encodes it in the target format. Data that is
+// converted to its own format is rewritten with [codec.Reformat] where possible, so
+// that its comments and layout survive.
+func (e *Engine) Transform(req *TransformRequest) (*TransformResponse, error) {
+	m := req.Metrics
+	defer m.begin(req.Sources())()
+
+	reformat := (req.To == "" || req.To == req.From) && codec.CanReformat(req.From) &&
+		(req.Options == nil || !req.Options.YAMLStream)
+	if reformat {
+		var buf bytes.Buffer
+		stop := m.track(diagnostic.StageDecode)
+		err := codec.Reformat(&buf, bytes.NewReader(req.Data), req.From, req.Options)
+		stop()
+		if err != nil {
+			return nil, diagnostic.Wrap(diagnostic.StageDecode, "error decoding data from format "+string(req.From), err)
+		}
+		if m != nil {
+			m.BytesOut = buf.Len()
+		}
+		return &TransformResponse{Output: buf.Bytes()}, nil
+	}
+
+	var options *codec.DecoderOptions
+	if req.Options != nil {
+		options = &codec.DecoderOptions{CSV: req.Options.CSV}
+	}
+	stop := m.track(diagnostic.StageDecode)
+	var v any
+	err := codec.NewDecoder(bytes.NewReader(req.Data), req.From, options).Decode(&v)
+	stop()
+	if err != nil {
+		return nil, diagnostic.Wrap(diagnostic.StageDecode, "error decoding data from format "+string(req.From), err)
+	}
+	to := req.To
+	if to == "" {
+		to = req.From
+	}
+	stop = m.track(diagnostic.StageEncode)
+	out, err := Encode(v, to, req.Options)
+	stop()
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		m.BytesOut = len(out)
+	}
+	return &TransformResponse{Output: out}, nil
+}
+
+// Encode encodes v in format.
+func Encode(v any, format codec.Format, options *codec.EncoderOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, format, options).Encode(v); err != nil {
+		return nil, diagnostic.Wrap(diagnostic.StageEncode, "error encoding data to format "+string(format), err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Transform(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      TransformRequest
+		expected string
+		errorMsg string
+	}{
+		{
+			name:     "JSONToYAML",
+			req:      TransformRequest{Data: []byte(`{"a": [1, 2]}`), From: codec.FormatJSON, To: codec.FormatYAML},
+			expected: "a:\n    - 1\n    - 2\n",
+		},
+		{
+			name: "DefaultTo",
+			req: TransformRequest{
+				Data:    []byte(`{"a":1}`),
+				From:    codec.FormatJSON,
+				Options: &codec.EncoderOptions{InsertSpaces: true, IndentSize: 2},
+			},
+			expected: "{\n  \"a\": 1\n}\n",
+		},
+		{
+			name: "ReformatYAML",
+			req: TransformRequest{
+				Data:    []byte("# Settings.\na:\n    b: 1 # One.\n"),
+				From:    codec.FormatYAML,
+				To:      codec.FormatYAML,
+				Options: &codec.EncoderOptions{InsertSpaces: true, IndentSize: 2},
+			},
+			expected: "# Settings.\na:\n  b: 1 # One.\n",
+		},
+		{
+			name:     "ReformatTOML",
+			req:      TransformRequest{Data: []byte("[a]\n# One.\nb = 1\n"), From: codec.FormatTOML},
+			expected: "[a]\n\t\t\t\t# One.\n\t\t\t\tb = 1\n",
+		},
+		{
+			name: "YAMLStreamOption",
+			req: TransformRequest{
+				Data:    []byte("# Dropped.\n- a: 1\n- b: 2\n"),
+				From:    codec.FormatYAML,
+				Options: &codec.EncoderOptions{YAMLStream: true},
+			},
+			expected: "a: 1\n---\nb: 2\n",
+		},
+		{
+			name:     "ReformatError",
+			req:      TransformRequest{Data: []byte("a: [\n"), From: codec.FormatYAML},
+			errorMsg: "error decoding data from format yaml",
+		},
+		{
+			name:     "DecodeError",
+			req:      TransformRequest{Data: []byte(`{`), From: codec.FormatJSON, To: codec.FormatYAML},
+			errorMsg: "error decoding data from format json",
+		},
+		{
+			name:     "UnsupportedFormat",
+			req:      TransformRequest{Data: []byte(`[1]`), From: codec.FormatJSON, To: "ini"},
+			errorMsg: "error encoding data to format ini",
+		},
+	}
+	e := New(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := e.Transform(&tt.req)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(resp.Output))
+		})
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"syscall/js"
 )
 
-var unint8ArrayConstructor = js.Global().Get("Uint8Array")
+var (
+	unint8ArrayConstructor = js.Global().Get("Uint8Array")
+	objectConstructor      = js.Global().Get("Object")
+)
 
 // getUint8ArrayLength returns the length of a [Uint8Array]. Will panic if the input is not a [Uint8Array].
 //
@@ -35,3 +38,36 @@ func CopyUint8Array(buffer *js.Value) ([]byte, int) {
 	n := js.CopyBytesToGo(dst, *buffer)
 	return dst, n
 }
+
+// IsUint8Array reports whether v is a [Uint8Array].
+//
+// [Uint8Array]: https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Global_Objects/Uint8Array
+func IsUint8Array(v js.Value) bool {
+	return v.InstanceOf(unint8ArrayConstructor)
+}
+
+// IsAbortSignal reports whether v is an [AbortSignal], or an object that behaves like
+// one: it has a boolean aborted property and an addEventListener method.
+//
+// [AbortSignal]: https://developer.mozilla.org/en-US/docs/Web/API/AbortSignal
+func IsAbortSignal(v js.Value) bool {
+	return v.Type() == js.TypeObject &&
+		v.Get("aborted").Type() == js.TypeBoolean &&
+		v.Get("addEventListener").Type() == js.TypeFunction
+}
+
+// CopyUint8ArrayMap copies the contents of a plain object whose values are [Uint8Array]s
+// to a map of byte slices, keyed by the object's own enumerable property names.
+// Will panic if any of the values is not a [Uint8Array].
+//
+// [Uint8Array]: https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Global_Objects/Uint8Array
+func CopyUint8ArrayMap(obj *js.Value) map[string][]byte {
+	keys := objectConstructor.Call("keys", *obj)
+	m := make(map[string][]byte, keys.Length())
+	for i := range keys.Length() {
+		key := keys.Index(i).String()
+		view := obj.Get(key)
+		m[key], _ = CopyUint8Array(&view)
+	}
+	return m
+}
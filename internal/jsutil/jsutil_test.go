@@ -43,3 +43,36 @@ func TestCopyUint8Array(t *testing.T) {
 	assert.Equal(t, len(bytes), n)
 	assert.Equal(t, bytes, copiedBytes)
 }
+
+func TestIsUint8Array(t *testing.T) {
+	assert.True(t, IsUint8Array(MakeUint8Array([]byte{1})))
+	assert.False(t, IsUint8Array(js.ValueOf("not a Uint8Array")))
+	assert.False(t, IsUint8Array(js.ValueOf(map[string]interface{}{})))
+}
+
+func TestIsAbortSignal(t *testing.T) {
+	controller := js.Global().Get("AbortController").New()
+	assert.True(t, IsAbortSignal(controller.Get("signal")))
+	assert.False(t, IsAbortSignal(controller))
+	assert.False(t, IsAbortSignal(js.ValueOf(map[string]interface{}{"aborted": true})))
+	assert.False(t, IsAbortSignal(js.Undefined()))
+}
+
+func TestCopyUint8ArrayMap(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		obj := js.ValueOf(map[string]interface{}{
+			"a.tmpl": MakeUint8Array([]byte("a")),
+			"b.tmpl": MakeUint8Array([]byte("bb")),
+		})
+		assert.Equal(t, map[string][]byte{
+			"a.tmpl": []byte("a"),
+			"b.tmpl": []byte("bb"),
+		}, CopyUint8ArrayMap(&obj))
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			CopyUint8ArrayMap(testutil.Ptr(js.ValueOf(map[string]interface{}{"a": 10})))
+		})
+	})
+}
@@ -19,3 +19,8 @@ type JSValueWrapper struct {
 func (w JSValueWrapper) Get(key string) util.JSValuer {
 	return JSValueWrapper{w.Value.Get(key)}
 }
+
+// TypeOf returns the name of the JavaScript type of the value.
+func (w JSValueWrapper) TypeOf() string {
+	return w.Value.Type().String()
+}
@@ -9,4 +9,6 @@ type Action uint8
 const (
 	ActionProcessTemplate Action = iota
 	ActionTransformData
+	ActionAnalyzeTemplate
+	ActionCheckTemplate
 )
@@ -4,8 +4,10 @@
 package playground
 
 import (
+	"encoding/json"
 	"syscall/js"
 
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
 	"github.com/bartventer/go-template-playground/internal/jsutil"
 )
 
@@ -17,6 +19,16 @@ func (a Action) SuccessResponse(data []byte) js.Value {
 	})
 }
 
+// JSONResponse returns a successful response for the given action, whose data is v
+// encoded as JSON.
+func (a Action) JSONResponse(v any) js.Value {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return a.ErrorResponse(err.Error())
+	}
+	return a.SuccessResponse(data)
+}
+
 // ErrorResponse returns an error response for the given action.
 func (a Action) ErrorResponse(errMessage string) js.Value {
 	return js.ValueOf(map[string]interface{}{
@@ -24,3 +36,35 @@ func (a Action) ErrorResponse(errMessage string) js.Value {
 		"error":  errMessage,
 	})
 }
+
+// DiagnosticsResponse returns an error response for the given action that also carries
+// the structured diagnostics describing the error.
+func (a Action) DiagnosticsResponse(errMessage string, diagnostics []diagnostic.Diagnostic) js.Value {
+	diags := make([]interface{}, len(diagnostics))
+	for i, d := range diagnostics {
+		diags[i] = diagnosticToJS(d)
+	}
+	return js.ValueOf(map[string]interface{}{
+		"action":      a.String(),
+		"error":       errMessage,
+		"diagnostics": diags,
+	})
+}
+
+func diagnosticToJS(d diagnostic.Diagnostic) map[string]interface{} {
+	m := map[string]interface{}{
+		"stage":    string(d.Stage),
+		"severity": string(d.Severity),
+		"line":     d.Line,
+		"column":   d.Column,
+		"offset":   d.Offset,
+		"message":  d.Message,
+	}
+	if d.Template != "" {
+		m["template"] = d.Template
+	}
+	if d.Code != "" {
+		m["code"] = d.Code
+	}
+	return m
+}
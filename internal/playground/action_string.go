@@ -10,11 +10,13 @@ func _() {
 	var x [1]struct{}
 	_ = x[ActionProcessTemplate-0]
 	_ = x[ActionTransformData-1]
+	_ = x[ActionAnalyzeTemplate-2]
+	_ = x[ActionCheckTemplate-3]
 }
 
-const _Action_name = "ProcessTemplateTransformData"
+const _Action_name = "ProcessTemplateTransformDataAnalyzeTemplateCheckTemplate"
 
-var _Action_index = [...]uint8{0, 15, 28}
+var _Action_index = [...]uint8{0, 15, 28, 43, 56}
 
 func (i Action) String() string {
 	if i >= Action(len(_Action_index)-1) {
@@ -0,0 +1,149 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"fmt"
+	"strconv"
+	"syscall/js"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// analyzeTemplate is a JavaScript function that parses a template without executing it
+// and reports the fields, variables, functions, template invocations and {{define}}
+// blocks it references, with their source positions.
+//
+// Parameters:
+//   - this: The JavaScript value representing the context in which the function is called.
+//   - args: A slice of JavaScript values containing the template data and optional
+//     template options, of which the name and delimiters are used.
+//
+// Returns:
+//   - A JavaScript object containing the JSON encoded report or an error message.
+//
+// TypeScript signature:
+//
+//	interface Reference {
+//	  name: string;
+//	  template: string;
+//	  line: number;
+//	  column: number;
+//	  offset: number;
+//	}
+//
+//	interface AnalysisReport {
+//	  fields: Reference[];
+//	  variables: Reference[];
+//	  functions: Reference[];
+//	  templates: Reference[];
+//	  defines: Reference[];
+//	}
+//
+//	declare function analyzeTemplate(
+//	  /** The byte array containing the template data, or a map of file names to template data. */
+//	  templateView: Uint8Array | Record<string, Uint8Array>,
+//	  /** Optional: Template options. */
+//	  options?: TemplateOptions,
+//	): { action: "analyzeTemplate"; data: Uint8Array /* JSON encoded AnalysisReport */ } | { action: "analyzeTemplate"; error: string; diagnostics?: Diagnostic[] };
+func analyzeTemplate(this js.Value, args []js.Value) (result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = ActionAnalyzeTemplate.ErrorResponse("recovered from panic: " + fmt.Sprint(r))
+		}
+	}()
+
+	if len(args) < 1 || len(args) > 2 {
+		return ActionAnalyzeTemplate.ErrorResponse("expected 1 to 2 arguments, got " + strconv.Itoa(len(args)))
+	}
+
+	tmplView := args[0]
+
+	options := new(tmpl.Options)
+	if len(args) == 2 {
+		if optionsJS := args[1]; optionsJS.Type() != js.TypeUndefined {
+			if err := options.UnmarshalJS(jsutil.JSValueWrapper{Value: optionsJS}); err != nil {
+				return ActionAnalyzeTemplate.ErrorResponse(err.Error())
+			}
+		}
+	}
+
+	req := &engine.AnalyzeRequest{Templates: copyTemplateView(&tmplView, options.RootName()), Options: *options}
+	report, err := defaultEngine().Analyze(req)
+	if err != nil {
+		return ActionAnalyzeTemplate.DiagnosticsResponse(err.Error(), diagnostic.FromError(err, req.Sources()))
+	}
+
+	return ActionAnalyzeTemplate.JSONResponse(report)
+}
+
+// checkTemplate is a JavaScript function that checks a template against its context
+// data without executing it. It reports unknown field paths, ranging over values that
+// are not iterable, and calls of undefined functions or with the wrong number of
+// arguments, as warnings.
+//
+// Parameters:
+//   - this: The JavaScript value representing the context in which the function is called.
+//   - args: A slice of JavaScript values containing the template data, context data, format
+//     and optional template options.
+//
+// Returns:
+//   - A JavaScript object containing the JSON encoded warnings or an error message.
+//
+// TypeScript signature:
+//
+//	declare function checkTemplate(
+//	  /** The byte array containing the template data, or a map of file names to template data. */
+//	  templateView: Uint8Array | Record<string, Uint8Array>,
+//	  /** The byte array containing the context data. */
+//	  dataView: Uint8Array,
+//	  /** The format of the context data. */
+//	  format: Format,
+//	  /** Optional: Template options. */
+//	  options?: TemplateOptions,
+//	): { action: "checkTemplate"; data: Uint8Array /* JSON encoded Diagnostic[] */ } | { action: "checkTemplate"; error: string; diagnostics?: Diagnostic[] };
+func checkTemplate(this js.Value, args []js.Value) (result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = ActionCheckTemplate.ErrorResponse("recovered from panic: " + fmt.Sprint(r))
+		}
+	}()
+
+	if len(args) < 3 || len(args) > 4 {
+		return ActionCheckTemplate.ErrorResponse("expected 3 to 4 arguments, got " + strconv.Itoa(len(args)))
+	}
+
+	tmplView, dataView, format := args[0], args[1], args[2]
+
+	options, decoder := new(tmpl.Options), new(codec.DecoderOptions)
+	if len(args) == 4 {
+		if optionsJS := args[3]; optionsJS.Type() != js.TypeUndefined {
+			if err := options.UnmarshalJS(jsutil.JSValueWrapper{Value: optionsJS}); err != nil {
+				return ActionCheckTemplate.ErrorResponse(err.Error())
+			}
+			if err := decoder.UnmarshalJS(jsutil.JSValueWrapper{Value: optionsJS}); err != nil {
+				return ActionCheckTemplate.ErrorResponse(err.Error())
+			}
+		}
+	}
+
+	dataBytes, _ := jsutil.CopyUint8Array(&dataView)
+	req := &engine.CheckRequest{
+		Templates: copyTemplateView(&tmplView, options.RootName()),
+		Data:      dataBytes,
+		Format:    codec.Format(format.String()),
+		Options:   *options,
+		Decoder:   decoder,
+	}
+	resp, err := defaultEngine().Check(req)
+	if err != nil {
+		return ActionCheckTemplate.DiagnosticsResponse(err.Error(), diagnostic.FromError(err, req.Sources()))
+	}
+
+	return ActionCheckTemplate.JSONResponse(resp.Diagnostics)
+}
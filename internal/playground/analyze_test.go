@@ -0,0 +1,178 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"encoding/json"
+	"syscall/js"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/analysis"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_analyzeTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []js.Value
+		shouldFail bool
+		errorMsg   string
+		wantFields []string
+	}{
+		{
+			name:       "ArgumentError",
+			args:       []js.Value{},
+			shouldFail: true,
+			errorMsg:   "expected 1 to 2 arguments, got 0",
+		},
+		{
+			name:       "Panic",
+			args:       []js.Value{js.ValueOf("not a Uint8Array")},
+			shouldFail: true,
+			errorMsg:   "recovered from panic",
+		},
+		{
+			name:       "SingleTemplate",
+			args:       []js.Value{jsutil.MakeUint8Array([]byte("{{.User.Name}} {{.Count | add 1}}"))},
+			wantFields: []string{".User.Name", ".Count"},
+		},
+		{
+			name: "MultiFileTemplate",
+			args: []js.Value{js.ValueOf(map[string]interface{}{
+				"a.tmpl": jsutil.MakeUint8Array([]byte(`{{template "b.tmpl" .A}}`)),
+				"b.tmpl": jsutil.MakeUint8Array([]byte(`{{.B}}`)),
+			})},
+			wantFields: []string{".A", ".B"},
+		},
+		{
+			name: "Delims",
+			args: []js.Value{
+				jsutil.MakeUint8Array([]byte("{{ literal }} [[.x]]")),
+				js.ValueOf(map[string]interface{}{"delims": []interface{}{"[[", "]]"}}),
+			},
+			wantFields: []string{".x"},
+		},
+		{
+			name: "OptionsError",
+			args: []js.Value{
+				jsutil.MakeUint8Array([]byte("[[.x]]")),
+				js.ValueOf(map[string]interface{}{"delims": []interface{}{"[["}}),
+			},
+			shouldFail: true,
+			errorMsg:   "delims must be an array of two non-empty strings",
+		},
+		{
+			name:       "ParseError",
+			args:       []js.Value{jsutil.MakeUint8Array([]byte("{{.Name"))},
+			shouldFail: true,
+			errorMsg:   "error parsing template: template: template:1: unclosed action",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := analyzeTemplate(js.Value{}, tc.args).(js.Value)
+			if tc.shouldFail {
+				assert.Contains(t, result.Get("error").String(), tc.errorMsg)
+				return
+			}
+			data, _ := jsutil.CopyUint8Array(testutil.Ptr(result.Get("data")))
+			var report analysis.Report
+			require.NoError(t, json.Unmarshal(data, &report))
+			fields := make([]string, len(report.Fields))
+			for i, f := range report.Fields {
+				fields[i] = f.Name
+			}
+			assert.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
+
+func Test_checkTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []js.Value
+		shouldFail bool
+		errorMsg   string
+		wantCodes  []string
+	}{
+		{
+			name:       "ArgumentError",
+			args:       []js.Value{},
+			shouldFail: true,
+			errorMsg:   "expected 3 to 4 arguments, got 0",
+		},
+		{
+			name: "Valid",
+			args: []js.Value{
+				jsutil.MakeUint8Array([]byte("{{.User.Name}}")),
+				jsutil.MakeUint8Array([]byte(`{"User": {"Name": "World"}}`)),
+				js.ValueOf("json"),
+			},
+			wantCodes: []string{},
+		},
+		{
+			name: "Warnings",
+			args: []js.Value{
+				jsutil.MakeUint8Array([]byte("{{.Usr.Name}}{{range .User.Name}}{{end}}")),
+				jsutil.MakeUint8Array([]byte("User:\n  Name: World\n")),
+				js.ValueOf("yaml"),
+			},
+			wantCodes: []string{analysis.CodeUnknownField, analysis.CodeNotIterable},
+		},
+		{
+			name: "Entry",
+			args: []js.Value{
+				js.ValueOf(map[string]interface{}{
+					"a.tmpl": jsutil.MakeUint8Array([]byte(`{{.A}}`)),
+					"b.tmpl": jsutil.MakeUint8Array([]byte(`{{.B}}`)),
+				}),
+				jsutil.MakeUint8Array([]byte(`{"A": 1}`)),
+				js.ValueOf("json"),
+				js.ValueOf(map[string]interface{}{"entry": "a.tmpl"}),
+			},
+			wantCodes: []string{},
+		},
+		{
+			name: "CSVOptions",
+			args: []js.Value{
+				jsutil.MakeUint8Array([]byte("{{range .}}{{.x}}{{end}}")),
+				jsutil.MakeUint8Array([]byte("a,1\nb,2\n")),
+				js.ValueOf("csv"),
+				js.ValueOf(map[string]interface{}{"csv": map[string]interface{}{"noHeader": true}}),
+			},
+			wantCodes: []string{analysis.CodeScalarField},
+		},
+		{
+			name: "DecodeError",
+			args: []js.Value{
+				jsutil.MakeUint8Array([]byte("{{.A}}")),
+				jsutil.MakeUint8Array([]byte(`{"A": 1`)),
+				js.ValueOf("json"),
+			},
+			shouldFail: true,
+			errorMsg:   "error decoding context data",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := checkTemplate(js.Value{}, tc.args).(js.Value)
+			if tc.shouldFail {
+				assert.Contains(t, result.Get("error").String(), tc.errorMsg)
+				return
+			}
+			data, _ := jsutil.CopyUint8Array(testutil.Ptr(result.Get("data")))
+			var diags []diagnostic.Diagnostic
+			require.NoError(t, json.Unmarshal(data, &diags))
+			codes := make([]string, len(diags))
+			for i, d := range diags {
+				codes[i] = d.Code
+			}
+			assert.Equal(t, tc.wantCodes, codes)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"syscall/js"
+
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/protocol"
+)
+
+// errAborted is the error message of the responses of aborted calls.
+const errAborted = "operation aborted"
+
+// contextFunc is a module function whose work stops once ctx is done.
+type contextFunc func(ctx context.Context, args []js.Value) any
+
+// withoutContext adapts a module function that runs to completion regardless of ctx.
+func withoutContext(fn func(this js.Value, args []js.Value) any) contextFunc {
+	return func(_ context.Context, args []js.Value) any {
+		return fn(js.Undefined(), args)
+	}
+}
+
+// async returns a JavaScript function that takes the arguments of fn, runs fn in a
+// goroutine and returns a Promise resolved with its result. The promise is never
+// rejected: errors are reported in the resolved response, as by the synchronous
+// functions.
+//
+// If the last argument is an [AbortSignal], it is not passed to fn. Once the signal is
+// aborted, the context of fn is canceled and the promise is resolved with the response
+// returned by aborted, without waiting for fn to return.
+//
+// TypeScript signatures:
+//
+//	declare function processTemplateAsync(...args: [...Parameters<typeof processTemplate>, signal?: AbortSignal]): Promise<ReturnType<typeof processTemplate>>;
+//	declare function transformDataAsync(...args: [...Parameters<typeof transformData>, signal?: AbortSignal]): Promise<ReturnType<typeof transformData>>;
+//	declare function analyzeTemplateAsync(...args: [...Parameters<typeof analyzeTemplate>, signal?: AbortSignal]): Promise<ReturnType<typeof analyzeTemplate>>;
+//	declare function checkTemplateAsync(...args: [...Parameters<typeof checkTemplate>, signal?: AbortSignal]): Promise<ReturnType<typeof checkTemplate>>;
+//	declare function dispatchAsync(request: Request, signal?: AbortSignal): Promise<Response>;
+//
+// [AbortSignal]: https://developer.mozilla.org/en-US/docs/Web/API/AbortSignal
+func async(fn contextFunc, aborted func(args []js.Value) any) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		var signal js.Value
+		if n := len(args); n > 0 && jsutil.IsAbortSignal(args[n-1]) {
+			signal, args = args[n-1], args[:n-1]
+		}
+
+		var executor js.Func
+		executor = js.FuncOf(func(this js.Value, promiseArgs []js.Value) any {
+			executor.Release()
+			settle(promiseArgs[0], signal, fn, aborted, args)
+			return nil
+		})
+		return js.Global().Get("Promise").New(executor)
+	})
+}
+
+// settle calls fn with args in a goroutine and resolves the promise with its result,
+// or with the response returned by aborted once signal is aborted.
+func settle(resolve, signal js.Value, fn contextFunc, aborted func(args []js.Value) any, args []js.Value) {
+	if signal.Truthy() && signal.Get("aborted").Bool() {
+		resolve.Invoke(aborted(args))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		once    sync.Once
+		onAbort js.Func
+	)
+	done := func(result any) {
+		once.Do(func() {
+			cancel()
+			if signal.Truthy() {
+				signal.Call("removeEventListener", "abort", onAbort)
+				onAbort.Release()
+			}
+			resolve.Invoke(result)
+		})
+	}
+	if signal.Truthy() {
+		onAbort = js.FuncOf(func(this js.Value, _ []js.Value) any {
+			done(aborted(args))
+			return nil
+		})
+		signal.Call("addEventListener", "abort", onAbort)
+	}
+
+	go func() {
+		done(fn(ctx, args))
+	}()
+}
+
+// abortedResponse returns a function reporting an aborted call of the action a.
+func (a Action) abortedResponse() func(args []js.Value) any {
+	return func([]js.Value) any {
+		return a.ErrorResponse(errAborted)
+	}
+}
+
+// abortedDispatch reports an aborted dispatch, echoing the ID and action of the request.
+func abortedDispatch(args []js.Value) any {
+	resp := &protocol.Response{Version: protocol.Version, Error: errAborted}
+	if len(args) == 1 && args[0].Type() == js.TypeObject {
+		if id := args[0].Get("id"); !id.IsUndefined() {
+			resp.ID = json.RawMessage(jsonObject.Call("stringify", id).String())
+		}
+		resp.Action = protocol.Action(stringField(args[0], "action"))
+	}
+	return responseToJS(resp)
+}
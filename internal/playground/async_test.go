@@ -0,0 +1,125 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// await waits for promise to be resolved and returns its value.
+func await(t *testing.T, promise js.Value) js.Value {
+	t.Helper()
+	require.True(t, promise.InstanceOf(js.Global().Get("Promise")))
+	ch := make(chan js.Value, 1)
+	then := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- args[0]
+		return nil
+	})
+	defer then.Release()
+	promise.Call("then", then)
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("promise was not resolved")
+		return js.Undefined()
+	}
+}
+
+// endlessArgs returns the arguments of a template that executes for a very long time.
+func endlessArgs() []js.Value {
+	return []js.Value{
+		jsutil.MakeUint8Array([]byte(`{{range .}}{{range $}}{{range $}}{{range $}}{{end}}{{end}}{{end}}{{end}}`)),
+		jsutil.MakeUint8Array([]byte(`[0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 73, 74, 75, 76, 77, 78, 79, 80, 81, 82, 83, 84, 85, 86, 87, 88, 89, 90, 91, 92, 93, 94, 95, 96, 97, 98, 99]`)),
+		js.ValueOf("json"),
+		js.ValueOf(map[string]any{"limits": map[string]any{"timeout": -1, "maxSteps": -1}}),
+	}
+}
+
+func Test_async(t *testing.T) {
+	processTemplateAsync := async(processTemplateContext, ActionProcessTemplate.abortedResponse())
+	defer processTemplateAsync.Release()
+
+	t.Run("Resolve", func(t *testing.T) {
+		for _, tc := range processTestCases {
+			t.Run(tc.name, func(t *testing.T) {
+				result := await(t, processTemplateAsync.Invoke(toAnySlice(tc.args)...))
+				if tc.shouldFail {
+					assert.Contains(t, result.Get("error").String(), tc.errorMsg)
+				} else {
+					resultBytes, _ := jsutil.CopyUint8Array(testutil.Ptr(result.Get("data")))
+					assert.Equal(t, tc.expected, string(resultBytes))
+				}
+			})
+		}
+	})
+
+	t.Run("Signal", func(t *testing.T) {
+		controller := js.Global().Get("AbortController").New()
+		result := await(t, processTemplateAsync.Invoke(
+			jsutil.MakeUint8Array([]byte("Hello, {{.}}!")),
+			jsutil.MakeUint8Array([]byte(`"World"`)),
+			"json",
+			controller.Get("signal"),
+		))
+		resultBytes, _ := jsutil.CopyUint8Array(testutil.Ptr(result.Get("data")))
+		assert.Equal(t, "Hello, World!", string(resultBytes), "the signal is not passed on")
+	})
+
+	t.Run("AlreadyAborted", func(t *testing.T) {
+		controller := js.Global().Get("AbortController").New()
+		controller.Call("abort")
+		args := append(toAnySlice(endlessArgs()), controller.Get("signal"))
+		result := await(t, processTemplateAsync.Invoke(args...))
+		assert.Equal(t, ActionProcessTemplate.String(), result.Get("action").String())
+		assert.Equal(t, errAborted, result.Get("error").String())
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		controller := js.Global().Get("AbortController").New()
+		args := append(toAnySlice(endlessArgs()), controller.Get("signal"))
+		promise := processTemplateAsync.Invoke(args...)
+		js.Global().Call("setTimeout", controller.Get("abort").Call("bind", controller), 20)
+		result := await(t, promise)
+		assert.Equal(t, errAborted, result.Get("error").String())
+	})
+}
+
+func Test_async_dispatch(t *testing.T) {
+	dispatchAsync := async(dispatchContext, abortedDispatch)
+	defer dispatchAsync.Release()
+
+	result := await(t, dispatchAsync.Invoke(map[string]any{
+		"id":       "req-1",
+		"action":   "ProcessTemplate",
+		"template": "Hello, {{.}}!",
+		"data":     `"World"`,
+		"format":   "json",
+	}))
+	assert.JSONEq(t,
+		`{"version":1,"id":"req-1","action":"ProcessTemplate","data":"Hello, World!"}`,
+		jsonObject.Call("stringify", result).String())
+
+	controller := js.Global().Get("AbortController").New()
+	controller.Call("abort")
+	result = await(t, dispatchAsync.Invoke(map[string]any{"id": 2, "action": "Capabilities"}, controller.Get("signal")))
+	assert.JSONEq(t,
+		`{"version":1,"id":2,"action":"Capabilities","error":"operation aborted"}`,
+		jsonObject.Call("stringify", result).String())
+}
+
+func toAnySlice(values []js.Value) []any {
+	s := make([]any, len(values))
+	for i, v := range values {
+		s[i] = v
+	}
+	return s
+}
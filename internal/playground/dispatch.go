@@ -0,0 +1,166 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"syscall/js"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/protocol"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+var jsonObject = js.Global().Get("JSON")
+
+// dispatch is a JavaScript function that handles a request of the versioned protocol
+// described in [protocol]. Unlike the other functions, it takes a single request
+// object with named fields, and echoes the ID of the request in its response, so that
+// callers can match out of order replies.
+//
+// Parameters:
+//   - this: The JavaScript value representing the context in which the function is called.
+//   - args: A slice of JavaScript values containing the request.
+//
+// Returns:
+//   - A JavaScript object containing the response.
+//
+// TypeScript signature:
+//
+//	type Source = string | Uint8Array;
+//
+//	interface Request {
+//	  /** Protocol version; defaults to the current version, 1. */
+//	  version?: number;
+//	  /** Any JSON value, echoed in the response. */
+//	  id?: unknown;
+//	  action: "ProcessTemplate" | "TransformData" | "AnalyzeTemplate" | "CheckTemplate" | "Capabilities";
+//	  /** A single template, or a map of file names to templates. */
+//	  template?: Source | Record<string, Source>;
+//	  /** Context data, or the data to transform. */
+//	  data?: Source;
+//	  /** Format of the context data. */
+//	  format?: Format;
+//	  /** Format of the data to transform. */
+//	  prevFormat?: Format;
+//	  /** Format to transform the data to; defaults to prevFormat. */
+//	  nextFormat?: Format;
+//	  options?: TemplateOptions | EncoderOptions;
+//	}
+//
+//	interface Response {
+//	  version: number;
+//	  id?: unknown;
+//	  action: Request["action"];
+//	  /** Output of ProcessTemplate and TransformData, or the report of the other actions. */
+//	  data?: string | AnalysisReport | Diagnostic[] | Capabilities;
+//	  error?: string;
+//	  diagnostics?: Diagnostic[];
+//	}
+//
+//	interface Capabilities {
+//	  moduleVersion: string;
+//	  goVersion: string;
+//	  protocol: number;
+//	  actions: Request["action"][];
+//	  formats: Format[];
+//	  encoderOptions: { options: string[]; defaultTabSize: number; defaultIndentSize: number; maxIndentSize: number };
+//	  engines: ("text" | "html")[];
+//	  missingKeys: ("default" | "zero" | "error")[];
+//	  functions: string[];
+//	  limits: { timeout: number; maxOutputBytes: number; maxSteps: number; maxDepth: number };
+//	}
+//
+//	declare function dispatch(request: Request): Response;
+func dispatch(this js.Value, args []js.Value) any {
+	return dispatchContext(context.Background(), args)
+}
+
+// dispatchContext is like dispatch, but aborts execution of templates once ctx is done.
+func dispatchContext(ctx context.Context, args []js.Value) (result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = responseToJS(&protocol.Response{
+				Version: protocol.Version,
+				Error:   "recovered from panic: " + fmt.Sprint(r),
+			})
+		}
+	}()
+
+	if len(args) != 1 {
+		return responseToJS(&protocol.Response{
+			Version: protocol.Version,
+			Error:   "expected 1 argument, got " + strconv.Itoa(len(args)),
+		})
+	}
+	return responseToJS(protocol.DispatchContext(ctx, defaultEngine(), requestFromJS(args[0])))
+}
+
+// requestFromJS copies a request object.
+func requestFromJS(v js.Value) *protocol.Request {
+	req := &protocol.Request{
+		Args: protocol.Args{
+			Data:       sourceBytes(v.Get("data")),
+			Format:     codec.Format(stringField(v, "format")),
+			PrevFormat: codec.Format(stringField(v, "prevFormat")),
+			NextFormat: codec.Format(stringField(v, "nextFormat")),
+		},
+		Action: protocol.Action(stringField(v, "action")),
+	}
+	if v.Get("version").Truthy() {
+		req.Version = v.Get("version").Int()
+	}
+	if id := v.Get("id"); !id.IsUndefined() {
+		req.ID = json.RawMessage(jsonObject.Call("stringify", id).String())
+	}
+	if options := v.Get("options"); options.Truthy() {
+		req.Options = jsutil.JSValueWrapper{Value: options}
+	}
+
+	switch template := v.Get("template"); {
+	case template.Type() == js.TypeString || jsutil.IsUint8Array(template):
+		req.Template = string(sourceBytes(template))
+	case template.Type() == js.TypeObject:
+		keys := js.Global().Get("Object").Call("keys", template)
+		req.Templates = make(tmpl.Files, keys.Length())
+		for i := range keys.Length() {
+			name := keys.Index(i).String()
+			req.Templates[name] = sourceBytes(template.Get(name))
+		}
+	}
+	return req
+}
+
+// stringField returns the string property key of v, or "" if it is not set.
+func stringField(v js.Value, key string) string {
+	if field := v.Get(key); field.Truthy() {
+		return field.String()
+	}
+	return ""
+}
+
+// sourceBytes returns the contents of a string or Uint8Array, or nil if v is neither.
+func sourceBytes(v js.Value) []byte {
+	switch {
+	case v.Type() == js.TypeString:
+		return []byte(v.String())
+	case jsutil.IsUint8Array(v):
+		b, _ := jsutil.CopyUint8Array(&v)
+		return b
+	}
+	return nil
+}
+
+// responseToJS converts resp to a plain JavaScript object.
+func responseToJS(resp *protocol.Response) js.Value {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		b, _ = json.Marshal(&protocol.Response{Version: resp.Version, ID: resp.ID, Action: resp.Action, Error: err.Error()})
+	}
+	return jsonObject.Call("parse", string(b))
+}
@@ -0,0 +1,106 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  map[string]any
+		expected string // JSON encoded response.
+	}{
+		{
+			name: "Render",
+			request: map[string]any{
+				"version":  1,
+				"id":       "req-1",
+				"action":   "ProcessTemplate",
+				"template": "Hello, {{.Name}}!",
+				"data":     jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+				"format":   "json",
+			},
+			expected: `{"version":1,"id":"req-1","action":"ProcessTemplate","data":"Hello, World!"}`,
+		},
+		{
+			name: "RenderFiles",
+			request: map[string]any{
+				"id":     7,
+				"action": "ProcessTemplate",
+				"template": map[string]any{
+					"a.tmpl": jsutil.MakeUint8Array([]byte(`{{template "b.tmpl" .}}`)),
+					"b.tmpl": "[{{.}}]",
+				},
+				"data":    "1",
+				"format":  "yaml",
+				"options": map[string]any{"entry": "a.tmpl"},
+			},
+			expected: `{"version":1,"id":7,"action":"ProcessTemplate","data":"[1]"}`,
+		},
+		{
+			name: "Transform",
+			request: map[string]any{
+				"action":     "TransformData",
+				"data":       `{"a":1}`,
+				"prevFormat": "json",
+				"nextFormat": "yaml",
+			},
+			expected: `{"version":1,"action":"TransformData","data":"a: 1\n"}`,
+		},
+		{
+			name:     "UnknownAction",
+			request:  map[string]any{"id": nil, "action": "processTemplate"},
+			expected: `{"version":1,"id":null,"action":"processTemplate","error":"unknown action \"processTemplate\""}`,
+		},
+		{
+			name:     "UnsupportedVersion",
+			request:  map[string]any{"version": 2, "action": "Capabilities"},
+			expected: `{"version":1,"action":"Capabilities","error":"unsupported protocol version 2; the supported version is 1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := dispatch(js.Undefined(), []js.Value{js.ValueOf(tt.request)})
+			v, ok := result.(js.Value)
+			require.True(t, ok)
+			assert.JSONEq(t, tt.expected, jsonObject.Call("stringify", v).String())
+		})
+	}
+}
+
+func Test_dispatch_capabilities(t *testing.T) {
+	result := dispatch(js.Undefined(), []js.Value{js.ValueOf(map[string]any{"action": "Capabilities"})})
+	v, ok := result.(js.Value)
+	require.True(t, ok)
+	data := v.Get("data")
+	assert.Equal(t, protocol.Version, data.Get("protocol").Int())
+	assert.Equal(t, "json", data.Get("formats").Index(0).String())
+	assert.Positive(t, data.Get("functions").Length())
+}
+
+func Test_dispatch_argumentError(t *testing.T) {
+	result := dispatch(js.Undefined(), nil)
+	v, ok := result.(js.Value)
+	require.True(t, ok)
+	assert.Equal(t, "expected 1 argument, got 0", v.Get("error").String())
+}
+
+func TestAction_protocol(t *testing.T) {
+	for action, name := range map[Action]protocol.Action{
+		ActionProcessTemplate: protocol.ActionProcessTemplate,
+		ActionTransformData:   protocol.ActionTransformData,
+		ActionAnalyzeTemplate: protocol.ActionAnalyzeTemplate,
+		ActionCheckTemplate:   protocol.ActionCheckTemplate,
+	} {
+		assert.Equal(t, string(name), action.String(), "the protocol names actions as the playground does")
+	}
+}
@@ -0,0 +1,43 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"syscall/js"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/engine"
+)
+
+// newMetrics returns the metrics to fill in for a call with the options optionsJS, or
+// nil if the options do not ask for metrics.
+func newMetrics(optionsJS js.Value) *engine.Metrics {
+	if optionsJS.Type() == js.TypeObject && optionsJS.Get("metrics").Truthy() {
+		return new(engine.Metrics)
+	}
+	return nil
+}
+
+// withMetrics adds m to the response resp, unless m is nil. It returns resp.
+func withMetrics(resp js.Value, m *engine.Metrics) js.Value {
+	if m == nil {
+		return resp
+	}
+	resp.Set("metrics", map[string]any{
+		"decode":     milliseconds(m.Decode),
+		"parse":      milliseconds(m.Parse),
+		"execute":    milliseconds(m.Execute),
+		"encode":     milliseconds(m.Encode),
+		"bytesIn":    m.BytesIn,
+		"bytesOut":   m.BytesOut,
+		"allocs":     m.Allocs,
+		"allocBytes": m.AllocBytes,
+	})
+	return resp
+}
+
+// milliseconds returns d in fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
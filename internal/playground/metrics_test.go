@@ -0,0 +1,95 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"slices"
+	"syscall/js"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_metrics(t *testing.T) {
+	metricsOption := map[string]any{"metrics": true}
+	tests := []struct {
+		name     string
+		call     func() any
+		bytesIn  int
+		bytesOut int
+		stages   []string // Stages expected to run; the others take no time.
+	}{
+		{
+			name: "ProcessTemplate",
+			call: func() any {
+				return processTemplate(js.Undefined(), []js.Value{
+					jsutil.MakeUint8Array([]byte("{{.}}")),
+					jsutil.MakeUint8Array([]byte(`"World"`)),
+					js.ValueOf("json"),
+					js.ValueOf(metricsOption),
+				})
+			},
+			bytesIn:  12,
+			bytesOut: 5,
+			stages:   []string{"decode", "parse", "execute"},
+		},
+		{
+			name: "ProcessTemplateError",
+			call: func() any {
+				return processTemplate(js.Undefined(), []js.Value{
+					jsutil.MakeUint8Array([]byte("{{.}}")),
+					jsutil.MakeUint8Array([]byte(`{`)),
+					js.ValueOf("json"),
+					js.ValueOf(metricsOption),
+				})
+			},
+			bytesIn: 6,
+			stages:  []string{"decode"},
+		},
+		{
+			name: "TransformData",
+			call: func() any {
+				return transformData(js.Undefined(), []js.Value{
+					jsutil.MakeUint8Array([]byte(`{"a":1}`)),
+					js.ValueOf("json"),
+					js.ValueOf("yaml"),
+					js.ValueOf(metricsOption),
+				})
+			},
+			bytesIn:  7,
+			bytesOut: 5,
+			stages:   []string{"decode", "encode"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := tt.call().(js.Value).Get("metrics")
+			require.Equal(t, js.TypeObject, metrics.Type())
+			assert.Equal(t, tt.bytesIn, metrics.Get("bytesIn").Int())
+			assert.Equal(t, tt.bytesOut, metrics.Get("bytesOut").Int())
+			assert.Positive(t, metrics.Get("allocs").Int())
+			assert.Positive(t, metrics.Get("allocBytes").Int())
+			for _, stage := range []string{"decode", "parse", "execute", "encode"} {
+				if slices.Contains(tt.stages, stage) {
+					// A stage can take no measurable time; bytesOut and allocs show
+					// that the call ran.
+					assert.GreaterOrEqual(t, metrics.Get(stage).Float(), 0.0, stage)
+				} else {
+					assert.Zero(t, metrics.Get(stage).Float(), stage)
+				}
+			}
+		})
+	}
+}
+
+func Test_metrics_disabled(t *testing.T) {
+	result := processTemplate(js.Undefined(), []js.Value{
+		jsutil.MakeUint8Array([]byte("{{.}}")),
+		jsutil.MakeUint8Array([]byte(`1`)),
+		js.ValueOf("json"),
+	}).(js.Value)
+	assert.True(t, result.Get("metrics").IsUndefined())
+}
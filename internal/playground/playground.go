@@ -1,30 +1,127 @@
 //go:build js && wasm
 // +build js,wasm
 
-// Package playground is a WebAssembly module that provides functions for transforming data and processing
+// Package playground is a WebAssembly module that provides functions for transforming data, and processing and analyzing
 // Go templates.
 //
 // See [codec] for information about the supported formats.
 // See [tmpl] for the supported template functions.
+//
+// The functions are set on the global object, or on a namespace object selected with
+// [WithNamespace], so that several modules can be loaded side by side. Once they are
+// set, the onReady function of the namespace object, if the host defined one, is called
+// with the namespace object. The shutdown function removes and releases the functions,
+// and lets [InitModule] return.
 package playground
 
-import "syscall/js"
+import (
+	"sync"
+	"syscall/js"
+)
 
 // Function names.
 const (
 	FuncNameTransformData   = "transformData"
 	FuncNameProcessTemplate = "processTemplate"
+	FuncNameAnalyzeTemplate = "analyzeTemplate"
+	FuncNameCheckTemplate   = "checkTemplate"
+	FuncNameDispatch        = "dispatch"
+
+	FuncNameTransformDataAsync   = "transformDataAsync"
+	FuncNameProcessTemplateAsync = "processTemplateAsync"
+	FuncNameAnalyzeTemplateAsync = "analyzeTemplateAsync"
+	FuncNameCheckTemplateAsync   = "checkTemplateAsync"
+	FuncNameDispatchAsync        = "dispatchAsync"
+
+	FuncNameProcessTemplateStream   = "processTemplateStream"
+	FuncNameProcessTemplateReadable = "processTemplateReadable"
+
+	FuncNameShutdown = "shutdown"
 )
 
-// InitModule initializes the WebAssembly module.
-func InitModule() {
-	for name, fn := range map[string]js.Func{
+// ReadyCallbackName is the name of the function of the namespace object that is called
+// once the functions are set.
+const ReadyCallbackName = "onReady"
+
+// config holds the settings of the module.
+type config struct {
+	namespace string
+}
+
+// Option configures the module.
+type Option func(*config)
+
+// WithNamespace sets the functions on the global object named namespace, which is
+// created if it does not exist, rather than on the global object itself. An empty
+// namespace selects the global object.
+//
+// TypeScript signature of the namespace object:
+//
+//	interface Namespace {
+//	  /** Called by the module once the functions are set; defined by the host. */
+//	  onReady?: (namespace: Namespace) => void;
+//	  /** Removes and releases the functions, and lets the Go program exit. */
+//	  shutdown(): void;
+//	  processTemplate: typeof processTemplate;
+//	  // ...and the other functions.
+//	}
+func WithNamespace(namespace string) Option {
+	return func(c *config) { c.namespace = namespace }
+}
+
+// InitModule initializes the WebAssembly module. It sets the functions and blocks until
+// they are shut down.
+func InitModule(opts ...Option) {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	global := js.Global()
+	ns := global
+	if c.namespace != "" {
+		if ns = global.Get(c.namespace); !ns.Truthy() {
+			ns = global.Get("Object").New()
+			global.Set(c.namespace, ns)
+			defer global.Delete(c.namespace)
+		}
+	}
+
+	var shutdown sync.Once
+	done := make(chan struct{})
+	funcs := map[string]js.Func{
 		FuncNameTransformData:   js.FuncOf(transformData),
 		FuncNameProcessTemplate: js.FuncOf(processTemplate),
-	} {
-		defer fn.Release()
-		js.Global().Set(name, fn)
+		FuncNameAnalyzeTemplate: js.FuncOf(analyzeTemplate),
+		FuncNameCheckTemplate:   js.FuncOf(checkTemplate),
+		FuncNameDispatch:        js.FuncOf(dispatch),
+
+		FuncNameTransformDataAsync:   async(withoutContext(transformData), ActionTransformData.abortedResponse()),
+		FuncNameProcessTemplateAsync: async(processTemplateContext, ActionProcessTemplate.abortedResponse()),
+		FuncNameAnalyzeTemplateAsync: async(withoutContext(analyzeTemplate), ActionAnalyzeTemplate.abortedResponse()),
+		FuncNameCheckTemplateAsync:   async(withoutContext(checkTemplate), ActionCheckTemplate.abortedResponse()),
+		FuncNameDispatchAsync:        async(dispatchContext, abortedDispatch),
+
+		FuncNameProcessTemplateStream:   async(processTemplateStream, ActionProcessTemplate.abortedResponse()),
+		FuncNameProcessTemplateReadable: js.FuncOf(processTemplateReadable),
+
+		FuncNameShutdown: js.FuncOf(func(this js.Value, args []js.Value) any {
+			shutdown.Do(func() { close(done) })
+			return nil
+		}),
 	}
+	for name, fn := range funcs {
+		ns.Set(name, fn)
+	}
+	defer func() {
+		for name, fn := range funcs {
+			ns.Delete(name)
+			fn.Release()
+		}
+	}()
 
-	select {}
+	if onReady := ns.Get(ReadyCallbackName); onReady.Type() == js.TypeFunction {
+		onReady.Invoke(ns)
+	}
+	<-done
 }
@@ -10,12 +10,34 @@ import (
 
 	"github.com/bartventer/go-template-playground/internal/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+var funcNames = []string{
+	FuncNameTransformData, FuncNameProcessTemplate, FuncNameAnalyzeTemplate, FuncNameCheckTemplate, FuncNameDispatch,
+	FuncNameTransformDataAsync, FuncNameProcessTemplateAsync, FuncNameAnalyzeTemplateAsync, FuncNameCheckTemplateAsync, FuncNameDispatchAsync,
+	FuncNameProcessTemplateStream, FuncNameProcessTemplateReadable,
+	FuncNameShutdown,
+}
+
+// waitReturn waits for done to be closed.
+func waitReturn(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("InitModule did not return after shutdown")
+	}
+}
+
 func TestInitModule(t *testing.T) {
-	go InitModule()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		InitModule()
+	}()
 
-	for _, name := range []string{FuncNameTransformData, FuncNameProcessTemplate} {
+	for _, name := range funcNames {
 		testutil.WaitForGlobalFunc(t, name,
 			testutil.WithTimeout(5*time.Second),
 			testutil.WithAssertion(func(v js.Value) assert.ValueAssertionFunc {
@@ -25,4 +47,62 @@ func TestInitModule(t *testing.T) {
 			}),
 		)
 	}
+
+	js.Global().Call(FuncNameShutdown)
+	waitReturn(t, done)
+	for _, name := range funcNames {
+		assert.True(t, js.Global().Get(name).IsUndefined(), "%s is removed", name)
+	}
+}
+
+func TestInitModule_namespace(t *testing.T) {
+	const namespace = "testPlayground"
+	ready := make(chan js.Value, 1)
+	onReady := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ready <- args[0]
+		return nil
+	})
+	defer onReady.Release()
+	js.Global().Set(namespace, map[string]any{ReadyCallbackName: onReady})
+	defer js.Global().Delete(namespace)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		InitModule(WithNamespace(namespace))
+	}()
+
+	var ns js.Value
+	select {
+	case ns = <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("onReady was not called")
+	}
+	require.True(t, ns.Equal(js.Global().Get(namespace)))
+	for _, name := range funcNames {
+		assert.Equal(t, js.TypeFunction, ns.Get(name).Type(), name)
+		assert.True(t, js.Global().Get(name).IsUndefined(), "%s is not global", name)
+	}
+	result := ns.Call(FuncNameProcessTemplate,
+		js.Global().Get("Uint8Array").New(0), js.Global().Get("Uint8Array").New(0), "json")
+	assert.True(t, result.Get("error").IsUndefined())
+
+	ns.Call(FuncNameShutdown)
+	waitReturn(t, done)
+	assert.True(t, ns.Get(FuncNameProcessTemplate).IsUndefined())
+	assert.Equal(t, js.TypeObject, js.Global().Get(namespace).Type(), "the namespace of the host is kept")
+}
+
+func TestInitModule_newNamespace(t *testing.T) {
+	const namespace = "newTestPlayground"
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		InitModule(WithNamespace(namespace))
+	}()
+
+	require.Eventually(t, func() bool { return js.Global().Get(namespace).Truthy() }, 5*time.Second, 10*time.Millisecond)
+	js.Global().Get(namespace).Call(FuncNameShutdown)
+	waitReturn(t, done)
+	assert.True(t, js.Global().Get(namespace).IsUndefined(), "the namespace created by the module is removed")
 }
@@ -4,117 +4,221 @@
 package playground
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"strconv"
 	"sync"
 	"syscall/js"
-	"text/template"
 
 	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/engine"
 	"github.com/bartventer/go-template-playground/internal/jsutil"
 	"github.com/bartventer/go-template-playground/internal/tmpl"
 )
 
-var (
-	templatePool   sync.Pool
-	dataReaderPool sync.Pool
-	dataBufPool    sync.Pool
-	initPoolsOnce  sync.Once
-)
-
-func initPools() {
-	initPoolsOnce.Do(func() {
-		templatePool = sync.Pool{
-			New: func() interface{} {
-				return template.New("template").Funcs(tmpl.TemplateFuncs())
-			},
-		}
-		dataReaderPool = sync.Pool{
-			New: func() interface{} {
-				return new(bytes.Reader)
-			},
-		}
-		dataBufPool = sync.Pool{
-			New: func() interface{} {
-				return new(bytes.Buffer)
-			},
-		}
-	})
-}
+// defaultEngine returns the engine shared by the module functions.
+var defaultEngine = sync.OnceValue(func() *engine.Engine {
+	return engine.New(tmpl.DefaultCacheSize)
+})
 
 // processTemplate is a JavaScript function that processes a template with a context.
 // It reads the template and context data from byte arrays and writes the result
 // to a byte array. The context data is decoded using the specified format.
 //
+// The template may also be given as an object mapping file names to byte arrays, in
+// which case all files are parsed into a single template set and the entry template
+// named in the options is executed.
+//
 // Parameters:
 //   - this: The JavaScript value representing the context in which the function is called.
-//   - args: A slice of JavaScript values containing the template data, context data, and format.
+//   - args: A slice of JavaScript values containing the template data, context data, format
+//     and optional template options.
 //
 // Returns:
 //   - A JavaScript object containing the processed template data or an error message.
 //
 // TypeScript signature:
 //
+//	interface Diagnostic {
+//	  stage: "decode" | "parse" | "execute" | "encode";
+//	  severity: "error" | "warning";
+//	  /** Name of the template source; absent for the context data. */
+//	  template?: string;
+//	  /** 1-based line, or 0 if unknown. */
+//	  line: number;
+//	  /** 1-based column, or 0 if unknown. */
+//	  column: number;
+//	  /** 0-based byte offset, or -1 if unknown. */
+//	  offset: number;
+//	  /** Machine-readable error code, such as an html/template ErrorCode name. */
+//	  code?: string;
+//	  message: string;
+//	}
+//
+//	interface TemplateOptions {
+//	  /** Name of the template to execute. */
+//	  entry?: string;
+//	  /** Template engine, "text" (text/template) or "html" (html/template); defaults to "text". */
+//	  engine?: "text" | "html";
+//	  /** Name of a single template, as used in error messages; defaults to "template". */
+//	  name?: string;
+//	  /** Left and right action delimiters; default to "{{" and "}}". */
+//	  delims?: [string, string];
+//	  /** Behavior when a map has no entry for a key; defaults to "default", which prints "<no value>". */
+//	  missingKey?: "default" | "zero" | "error";
+//	  /** Fixes the results of now, date and randInt, for reproducible output. */
+//	  deterministic?: {
+//	    /** RFC 3339 instant returned by now and date; defaults to the current time. */
+//	    now?: string;
+//	    /** IANA time zone of now and date, such as "Europe/Berlin"; defaults to local time. */
+//	    timeZone?: string;
+//	    /** Seed of the random source used by randInt; defaults to 0. */
+//	    seed?: number;
+//	  };
+//	  /** Execution limits; omitted or zero limits use the defaults, negative limits are disabled. */
+//	  limits?: {
+//	    /** Wall-clock deadline in milliseconds; defaults to 5000. */
+//	    timeout?: number;
+//	    /** Maximum output size in bytes; defaults to 8 MiB. */
+//	    maxOutputBytes?: number;
+//	    /** Maximum number of range iterations and template calls; defaults to 1000000. */
+//	    maxSteps?: number;
+//	    /** Maximum nesting depth of template calls; defaults to 256. */
+//	    maxDepth?: number;
+//	  };
+//	  /** Settings of decoding csv and tsv context data; see transformData. */
+//	  csv?: CSVOptions;
+//	  /** Whether to add the metrics of the call to the result. */
+//	  metrics?: boolean;
+//	  /** Whether to add the trace of the evaluated pipelines to the result. */
+//	  trace?: boolean;
+//	}
+//
+//	interface Metrics {
+//	  /** Durations of the stages in milliseconds; zero for stages that did not run. */
+//	  decode: number;
+//	  parse: number;
+//	  execute: number;
+//	  encode: number;
+//	  /** Size of the templates and data, and of the output, in bytes. */
+//	  bytesIn: number;
+//	  bytesOut: number;
+//	  /** Number of Go heap objects and bytes allocated during the call. */
+//	  allocs: number;
+//	  allocBytes: number;
+//	}
+//
+//	interface TraceEvent {
+//	  kind: "action" | "if" | "range" | "with" | "template";
+//	  /** The pipeline, as printed by the parser. */
+//	  pipeline: string;
+//	  /** Name of the template source. */
+//	  template: string;
+//	  /** Position of the pipeline; line and column are 1-based, offset is 0-based. */
+//	  line: number;
+//	  column: number;
+//	  offset: number;
+//	  /** Values of dot and of the pipeline, formatted like %v and truncated to 200 bytes. */
+//	  dot: string;
+//	  value: string;
+//	  /** Go type of the value of the pipeline. */
+//	  type: string;
+//	}
+//
+//	interface Trace {
+//	  /** The evaluated pipelines, in order; at most 10000. */
+//	  events: TraceEvent[];
+//	  /** Whether later events were dropped. */
+//	  truncated: boolean;
+//	}
+//
 //	declare function processTemplate(
-//	  /** The byte array containing the template data. */
-//	  templateView: Uint8Array,
+//	  /** The byte array containing the template data, or a map of file names to template data. */
+//	  templateView: Uint8Array | Record<string, Uint8Array>,
 //	  /** The byte array containing the context data. */
 //	  dataView: Uint8Array,
 //	  /** The format of the context data. */
 //	  format: Format,
-//	): { action: "processTemplate"; data: Uint8Array } | { action: "processTemplate"; error: string };
-func processTemplate(this js.Value, args []js.Value) (result any) {
+//	  /** Optional: Template options. */
+//	  options?: TemplateOptions,
+//	): ({ action: "processTemplate"; data: Uint8Array } | { action: "processTemplate"; error: string; diagnostics?: Diagnostic[] }) & { metrics?: Metrics; trace?: Trace };
+func processTemplate(this js.Value, args []js.Value) any {
+	return processTemplateContext(context.Background(), args)
+}
+
+// processTemplateContext is like processTemplate, but aborts execution of the template
+// once ctx is done.
+func processTemplateContext(ctx context.Context, args []js.Value) (result any) {
 	defer func() {
 		if r := recover(); r != nil {
 			result = ActionProcessTemplate.ErrorResponse("recovered from panic: " + fmt.Sprint(r))
 		}
 	}()
 
-	if len(args) != 3 {
-		return ActionProcessTemplate.ErrorResponse("expected 3 arguments, got " + strconv.Itoa(len(args)))
+	if len(args) < 3 || len(args) > 4 {
+		return ActionProcessTemplate.ErrorResponse("expected 3 to 4 arguments, got " + strconv.Itoa(len(args)))
 	}
 
-	tmplView, dataView, format := args[0], args[1], args[2]
-	tmplBytes, n := jsutil.CopyUint8Array(&tmplView)
-	if n == 0 {
-		return ActionProcessTemplate.SuccessResponse([]byte{})
+	var options js.Value
+	if len(args) == 4 {
+		options = args[3]
 	}
-
-	dataBytes, _ := jsutil.CopyUint8Array(&dataView)
-	resultBytes, err := processTemplateBytes(tmplBytes, dataBytes, format.String())
+	req, err := renderRequest(args[0], args[1], args[2], options)
 	if err != nil {
 		return ActionProcessTemplate.ErrorResponse(err.Error())
 	}
+	if req == nil {
+		return ActionProcessTemplate.SuccessResponse([]byte{})
+	}
+	resp, err := defaultEngine().RenderContext(ctx, req)
+	if err != nil {
+		return withTrace(withMetrics(ActionProcessTemplate.DiagnosticsResponse(err.Error(), diagnostic.FromError(err, req.Sources())), req.Metrics), req.Trace)
+	}
 
-	return ActionProcessTemplate.SuccessResponse(resultBytes)
+	return withTrace(withMetrics(ActionProcessTemplate.SuccessResponse(resp.Output), req.Metrics), req.Trace)
 }
 
-func processTemplateBytes(tmplBytes, ctxBytes []byte, format string) ([]byte, error) {
-	initPools()
-	ctxReader := dataReaderPool.Get().(*bytes.Reader)
-	ctxReader.Reset(ctxBytes)
-	defer dataReaderPool.Put(ctxReader)
-
-	decoder := codec.NewDecoder(ctxReader, codec.Format(format))
-	var ctxData interface{}
-	if err := decoder.Decode(&ctxData); err != nil {
-		return nil, fmt.Errorf("error decoding context data: %w", err)
+// renderRequest copies the template, context data, format and optional template options
+// of a call into a render request. It returns a nil request if the template is empty.
+func renderRequest(tmplView, dataView, format, optionsJS js.Value) (*engine.RenderRequest, error) {
+	options := new(tmpl.Options)
+	if optionsJS.Truthy() {
+		if err := options.UnmarshalJS(jsutil.JSValueWrapper{Value: optionsJS}); err != nil {
+			return nil, err
+		}
 	}
 
-	tmpl, err := templatePool.Get().(*template.Template).Parse(string(tmplBytes))
-	if err != nil {
-		return nil, fmt.Errorf("error parsing template: %w", err)
+	single := jsutil.IsUint8Array(tmplView)
+	files := copyTemplateView(&tmplView, options.RootName())
+	if len(files) == 0 || (single && len(files[options.RootName()]) == 0) {
+		return nil, nil
 	}
-	defer templatePool.Put(tmpl)
-
-	resultBuf := dataBufPool.Get().(*bytes.Buffer)
-	resultBuf.Reset()
-	defer dataBufPool.Put(resultBuf)
-
-	if err := tmpl.Execute(resultBuf, ctxData); err != nil {
-		return nil, fmt.Errorf("error executing template: %w", err)
+	decoder := new(codec.DecoderOptions)
+	if optionsJS.Truthy() {
+		if err := decoder.UnmarshalJS(jsutil.JSValueWrapper{Value: optionsJS}); err != nil {
+			return nil, err
+		}
 	}
+	dataBytes, _ := jsutil.CopyUint8Array(&dataView)
+	return &engine.RenderRequest{
+		Templates: files,
+		Data:      dataBytes,
+		Format:    codec.Format(format.String()),
+		Options:   *options,
+		Decoder:   decoder,
+		Metrics:   newMetrics(optionsJS),
+		Trace:     newTrace(optionsJS),
+	}, nil
+}
 
-	return resultBuf.Bytes(), nil
+// copyTemplateView copies a template argument, which is either a Uint8Array holding a
+// single template or an object mapping file names to Uint8Arrays. A single template
+// is returned under name.
+func copyTemplateView(view *js.Value, name string) tmpl.Files {
+	if jsutil.IsUint8Array(*view) {
+		tmplBytes, _ := jsutil.CopyUint8Array(view)
+		return tmpl.Files{name: tmplBytes}
+	}
+	return jsutil.CopyUint8ArrayMap(view)
 }
@@ -9,26 +9,25 @@ import (
 
 	"github.com/bartventer/go-template-playground/internal/jsutil"
 	"github.com/bartventer/go-template-playground/internal/testutil"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 var processTestCases = []struct {
-	name                string
-	args                []js.Value
-	expected            string
-	shouldFail          bool
-	errorMsg            string
-	skipBytesProcessing bool
+	name       string
+	args       []js.Value
+	expected   string
+	shouldFail bool
+	errorMsg   string
 }{
 	{
 		name: "ArgumentError",
 		args: []js.Value{
 			js.ValueOf("not a Uint8Array"),
 		},
-		shouldFail:          true,
-		errorMsg:            "expected 3 arguments",
-		skipBytesProcessing: true,
+		shouldFail: true,
+		errorMsg:   "expected 3 to 4 arguments",
 	},
 	{
 		name: "Panic",
@@ -37,9 +36,8 @@ var processTestCases = []struct {
 			js.Undefined(),
 			js.Undefined(),
 		},
-		shouldFail:          true,
-		errorMsg:            "recovered from panic",
-		skipBytesProcessing: true,
+		shouldFail: true,
+		errorMsg:   "recovered from panic",
 	},
 	{
 		name: "EmptyTemplate",
@@ -89,6 +87,203 @@ var processTestCases = []struct {
 		shouldFail: true,
 		errorMsg:   "error executing template",
 	},
+	{
+		name: "EntryOption",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`{{define "greeting"}}Hi, {{.Name}}!{{end}}ignored`)),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"entry": "greeting"}),
+		},
+		expected: "Hi, World!",
+	},
+	{
+		name: "UnknownEntryError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte("Hello, {{.Name}}!")),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"entry": "missing"}),
+		},
+		shouldFail: true,
+		errorMsg:   `error selecting entry template: template "missing" is not defined`,
+	},
+	{
+		name: "OptionsError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte("Hello, {{.Name}}!")),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf("not an object"),
+		},
+		shouldFail: true,
+		errorMsg:   "syscall/js: call of Value.Get on string",
+	},
+	{
+		name: "HTMLEngine",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`<a href="/{{.Path}}">{{.Name}}</a>`)),
+			jsutil.MakeUint8Array([]byte(`{"Name": "<World>", "Path": "a b"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"engine": "html"}),
+		},
+		expected: `<a href="/a%20b">&lt;World&gt;</a>`,
+	},
+	{
+		name: "HTMLEngineEscapeError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`<a href="{{if .}}/x{{else}}/y?{{end}}{{.}}">`)),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"engine": "html"}),
+		},
+		shouldFail: true,
+		errorMsg:   "error executing template: ErrAmbigContext: html/template:template:1:39:",
+	},
+	{
+		name: "UnsupportedEngineError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte("Hello, {{.Name}}!")),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"engine": "jinja"}),
+		},
+		shouldFail: true,
+		errorMsg:   "error parsing template: unsupported engine: jinja",
+	},
+	{
+		name: "MultiFileTemplate",
+		args: []js.Value{
+			js.ValueOf(map[string]interface{}{
+				"index.tmpl":  jsutil.MakeUint8Array([]byte(`{{template "header.tmpl" .}}Body`)),
+				"header.tmpl": jsutil.MakeUint8Array([]byte(`Hello, {{.Name}}! `)),
+			}),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"entry": "index.tmpl"}),
+		},
+		expected: "Hello, World! Body",
+	},
+	{
+		name: "MultiFileEmpty",
+		args: []js.Value{
+			js.ValueOf(map[string]interface{}{}),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+		},
+		expected: "",
+	},
+	{
+		name: "MultiFileNoEntryError",
+		args: []js.Value{
+			js.ValueOf(map[string]interface{}{
+				"a.tmpl": jsutil.MakeUint8Array([]byte(`a`)),
+				"b.tmpl": jsutil.MakeUint8Array([]byte(`b`)),
+			}),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+		},
+		shouldFail: true,
+		errorMsg:   "no entry template specified",
+	},
+	{
+		name: "DelimsOption",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte("{{ .Name }} [[.Name]]")),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"delims": []interface{}{"[[", "]]"}}),
+		},
+		expected: "{{ .Name }} World",
+	},
+	{
+		name: "MissingKeyError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte("Hello, {{.Nme}}!")),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"missingKey": "error", "name": "greeting.tmpl"}),
+		},
+		shouldFail: true,
+		errorMsg:   `template: greeting.tmpl:1:9: executing "greeting.tmpl" at <.Nme>: map has no entry for key "Nme"`,
+	},
+	{
+		name: "MissingKeyOptionError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte("Hello, {{.Name}}!")),
+			jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"missingKey": "ignore"}),
+		},
+		shouldFail: true,
+		errorMsg:   "error parsing template: unsupported missingkey: ignore",
+	},
+	{
+		name: "DeterministicOption",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`{{now.Format "15:04 MST"}} {{date}}`)),
+			jsutil.MakeUint8Array([]byte(`{}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"deterministic": map[string]interface{}{
+				"now":      "2024-06-30T22:15:00Z",
+				"timeZone": "America/New_York",
+			}}),
+		},
+		expected: "18:15 EDT 2024-06-30",
+	},
+	{
+		name: "CSVOption",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`{{range .}}{{index . 0}}:{{index . 1}} {{end}}`)),
+			jsutil.MakeUint8Array([]byte("a|1\nb|2\n")),
+			js.ValueOf("csv"),
+			js.ValueOf(map[string]interface{}{"csv": map[string]interface{}{"delimiter": "|", "noHeader": true}}),
+		},
+		expected: "a:1 b:2 ",
+	},
+	{
+		name: "CSVOptionError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`{{.}}`)),
+			jsutil.MakeUint8Array([]byte("a\n")),
+			js.ValueOf("csv"),
+			js.ValueOf(map[string]interface{}{"csv": map[string]interface{}{"delimiter": "||"}}),
+		},
+		shouldFail: true,
+		errorMsg:   "csv delimiter must be a single character",
+	},
+	{
+		name: "StepLimitError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`{{range 100000000}}{{end}}`)),
+			jsutil.MakeUint8Array([]byte(`{}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"limits": map[string]interface{}{"maxSteps": 1000}}),
+		},
+		shouldFail: true,
+		errorMsg:   "error executing template: ErrStepLimit",
+	},
+	{
+		name: "DepthLimitError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`{{define "r"}}{{template "r" .}}{{end}}{{template "r" .}}`)),
+			jsutil.MakeUint8Array([]byte(`{}`)),
+			js.ValueOf("json"),
+		},
+		shouldFail: true,
+		errorMsg:   "error executing template: ErrDepthLimit",
+	},
+	{
+		name: "OutputLimitError",
+		args: []js.Value{
+			jsutil.MakeUint8Array([]byte(`{{range 100}}abc{{end}}`)),
+			jsutil.MakeUint8Array([]byte(`{}`)),
+			js.ValueOf("json"),
+			js.ValueOf(map[string]interface{}{"engine": "html", "limits": map[string]interface{}{"maxOutputBytes": 10}}),
+		},
+		shouldFail: true,
+		errorMsg:   "error executing template: ErrOutputLimit: limit exceeded: output is larger than 10 bytes",
+	},
 }
 
 func Test_processTemplate(t *testing.T) {
@@ -106,23 +301,83 @@ func Test_processTemplate(t *testing.T) {
 	}
 }
 
-func Test_processTemplateBytes(t *testing.T) {
-	for _, tc := range processTestCases {
-		if tc.skipBytesProcessing {
-			t.Skip("skipping test that requires byte array copying")
-		}
-		t.Run(tc.name, func(t *testing.T) {
-			tmplBytes, _ := jsutil.CopyUint8Array(testutil.Ptr(tc.args[0]))
-			dataBytes, _ := jsutil.CopyUint8Array(testutil.Ptr(tc.args[1]))
-			format := tc.args[2].String()
-			result, err := processTemplateBytes(tmplBytes, dataBytes, format)
-			if tc.shouldFail {
-				require.Error(t, err)
-				assert.Contains(t, err.Error(), tc.errorMsg)
-			} else {
-				require.NoError(t, err)
-				assert.Equal(t, tc.expected, string(result))
-			}
-		})
+func Test_processTemplate_diagnostics(t *testing.T) {
+	result := processTemplate(js.Value{}, []js.Value{
+		jsutil.MakeUint8Array([]byte("Hello,\n{{.Name true}}!")),
+		jsutil.MakeUint8Array([]byte(`{"Name": "World"}`)),
+		js.ValueOf("json"),
+	}).(js.Value)
+
+	assert.Contains(t, result.Get("error").String(), "error executing template")
+	diagnostics := result.Get("diagnostics")
+	require.Equal(t, 1, diagnostics.Length())
+	d := diagnostics.Index(0)
+	assert.Equal(t, "execute", d.Get("stage").String())
+	assert.Equal(t, "error", d.Get("severity").String())
+	assert.Equal(t, tmpl.DefaultName, d.Get("template").String())
+	assert.Equal(t, 2, d.Get("line").Int())
+	assert.Equal(t, 3, d.Get("column").Int())
+	assert.Equal(t, 9, d.Get("offset").Int())
+	assert.True(t, d.Get("code").IsUndefined())
+}
+
+func Test_processTemplate_nameDiagnostics(t *testing.T) {
+	result := processTemplate(js.Value{}, []js.Value{
+		jsutil.MakeUint8Array([]byte("Hello, [[.Name")),
+		jsutil.MakeUint8Array([]byte(`{}`)),
+		js.ValueOf("json"),
+		js.ValueOf(map[string]interface{}{"name": "greeting.tmpl", "delims": []interface{}{"[[", "]]"}}),
+	}).(js.Value)
+
+	diagnostics := result.Get("diagnostics")
+	require.Equal(t, 1, diagnostics.Length())
+	d := diagnostics.Index(0)
+	assert.Equal(t, "parse", d.Get("stage").String())
+	assert.Equal(t, "greeting.tmpl", d.Get("template").String())
+	assert.Equal(t, 1, d.Get("line").Int())
+	assert.Equal(t, 0, d.Get("offset").Int())
+}
+
+func Test_processTemplate_limitDiagnostics(t *testing.T) {
+	result := processTemplate(js.Value{}, []js.Value{
+		jsutil.MakeUint8Array([]byte("{{range .}}\n{{range .}}{{end}}{{end}}")),
+		jsutil.MakeUint8Array([]byte(`[[1, 2, 3], [4, 5, 6]]`)),
+		js.ValueOf("json"),
+		js.ValueOf(map[string]interface{}{"limits": map[string]interface{}{"maxSteps": 4}}),
+	}).(js.Value)
+
+	diagnostics := result.Get("diagnostics")
+	require.Equal(t, 1, diagnostics.Length())
+	d := diagnostics.Index(0)
+	assert.Equal(t, "execute", d.Get("stage").String())
+	assert.Equal(t, "ErrStepLimit", d.Get("code").String())
+	assert.Equal(t, 2, d.Get("line").Int())
+	assert.Equal(t, 9, d.Get("column").Int())
+	assert.Equal(t, "limit exceeded: execution took more than 4 steps", d.Get("message").String())
+}
+
+func Test_processTemplate_cache(t *testing.T) {
+	render := func(tmplSrc, data string) js.Value {
+		return processTemplate(js.Value{}, []js.Value{
+			jsutil.MakeUint8Array([]byte(tmplSrc)),
+			jsutil.MakeUint8Array([]byte(data)),
+			js.ValueOf("json"),
+		}).(js.Value)
+	}
+	data := func(result js.Value) string {
+		b, _ := jsutil.CopyUint8Array(testutil.Ptr(result.Get("data")))
+		return string(b)
 	}
+
+	t.Run("ChangingData", func(t *testing.T) {
+		const src = `{{define "greet"}}Hello, {{.}}!{{end}}{{template "greet" .Name}}`
+		assert.Equal(t, "Hello, World!", data(render(src, `{"Name": "World"}`)))
+		assert.Equal(t, "Hello, Gopher!", data(render(src, `{"Name": "Gopher"}`)))
+	})
+
+	t.Run("NoDefineLeak", func(t *testing.T) {
+		render(`{{define "leak"}}leaked{{end}}`, `{}`)
+		result := render(`{{template "leak"}}`, `{}`)
+		assert.Contains(t, result.Get("error").String(), `template "leak" not defined`)
+	})
 }
@@ -0,0 +1,322 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"syscall/js"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+)
+
+// DefaultChunkSize is the default size of the chunks of streamed output.
+const DefaultChunkSize = 64 << 10
+
+// processTemplateStream is a JavaScript function that processes a template like
+// processTemplate, but passes the output to a callback in chunks as execution produces
+// it, rather than returning it once execution completes. If the callback returns a
+// promise, execution waits for it to settle before it continues, so that a slow
+// consumer holds back the producer. A rejected promise or an exception thrown by the
+// callback aborts execution.
+//
+// Parameters:
+//   - this: The JavaScript value representing the context in which the function is called.
+//   - args: A slice of JavaScript values containing the template data, context data, format,
+//     template options, callback and optional chunk size.
+//
+// Returns:
+//   - A JavaScript object containing the number of bytes passed to the callback, and an
+//     error message if execution failed.
+//
+// It is registered as the asynchronous function processTemplateStream, which also takes
+// an optional trailing AbortSignal; see async.
+//
+// TypeScript signature:
+//
+//	interface StreamStatus {
+//	  action: "processTemplate";
+//	  /** Number of bytes of output passed on, including those written before an error. */
+//	  bytes: number;
+//	  error?: string;
+//	  diagnostics?: Diagnostic[];
+//	  metrics?: Metrics;
+//	  trace?: Trace;
+//	}
+//
+//	declare function processTemplateStream(
+//	  templateView: Uint8Array | Record<string, Uint8Array>,
+//	  dataView: Uint8Array,
+//	  format: Format,
+//	  options: TemplateOptions | undefined,
+//	  /** Called with each chunk of output; the chunk is not reused. */
+//	  onChunk: (chunk: Uint8Array) => void | PromiseLike<void>,
+//	  /** Optional: Maximum size of a chunk in bytes; defaults to 64 KiB. */
+//	  chunkSize?: number,
+//	  signal?: AbortSignal,
+//	): Promise<StreamStatus>;
+func processTemplateStream(ctx context.Context, args []js.Value) (result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = ActionProcessTemplate.ErrorResponse("recovered from panic: " + fmt.Sprint(r))
+		}
+	}()
+
+	if len(args) < 5 || len(args) > 6 {
+		return ActionProcessTemplate.ErrorResponse("expected 5 to 6 arguments, got " + strconv.Itoa(len(args)))
+	}
+	onChunk := args[4]
+	if onChunk.Type() != js.TypeFunction {
+		return ActionProcessTemplate.ErrorResponse("onChunk must be a function")
+	}
+	var chunkSize js.Value
+	if len(args) == 6 {
+		chunkSize = args[5]
+	}
+
+	return streamTemplate(ctx, args[:4], chunkSize, func(chunk []byte) error {
+		result, err := invoke(onChunk, jsutil.MakeUint8Array(chunk))
+		if err != nil {
+			return err
+		}
+		return awaitThenable(ctx, result)
+	})
+}
+
+// processTemplateReadable is a JavaScript function that processes a template like
+// processTemplateStream, but returns the output as a ReadableStream of chunks. Execution
+// pauses while the queue of the stream is full, and stops if the stream is canceled.
+// The stream is errored if execution fails, and the status reports why.
+//
+// Parameters:
+//   - this: The JavaScript value representing the context in which the function is called.
+//   - args: A slice of JavaScript values containing the template data, context data, format,
+//     and optional template options and chunk size.
+//
+// Returns:
+//   - A JavaScript object containing the stream and a Promise of its final status.
+//
+// TypeScript signature:
+//
+//	declare function processTemplateReadable(
+//	  templateView: Uint8Array | Record<string, Uint8Array>,
+//	  dataView: Uint8Array,
+//	  format: Format,
+//	  options?: TemplateOptions,
+//	  /** Optional: Maximum size of a chunk in bytes; defaults to 64 KiB. */
+//	  chunkSize?: number,
+//	): { stream: ReadableStream<Uint8Array>; status: Promise<StreamStatus> };
+func processTemplateReadable(this js.Value, args []js.Value) (result any) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = ActionProcessTemplate.ErrorResponse("recovered from panic: " + fmt.Sprint(r))
+		}
+	}()
+
+	if len(args) < 3 || len(args) > 5 {
+		return ActionProcessTemplate.ErrorResponse("expected 3 to 5 arguments, got " + strconv.Itoa(len(args)))
+	}
+	args = append(args, make([]js.Value, 5-len(args))...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pulled := make(chan struct{}, 1)
+	var controller js.Value
+	var start, pull, cancelStream js.Func
+	start = js.FuncOf(func(this js.Value, args []js.Value) any {
+		controller = args[0]
+		return nil
+	})
+	pull = js.FuncOf(func(this js.Value, args []js.Value) any {
+		select {
+		case pulled <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	cancelStream = js.FuncOf(func(this js.Value, args []js.Value) any {
+		cancel()
+		return nil
+	})
+	stream := js.Global().Get("ReadableStream").New(map[string]any{
+		"start":  start,
+		"pull":   pull,
+		"cancel": cancelStream,
+	})
+
+	var executor js.Func
+	executor = js.FuncOf(func(this js.Value, promiseArgs []js.Value) any {
+		executor.Release()
+		resolve := promiseArgs[0]
+		go func() {
+			defer func() {
+				cancel()
+				start.Release()
+				pull.Release()
+				cancelStream.Release()
+			}()
+			status := streamTemplate(ctx, args[:4], args[4], func(chunk []byte) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if _, err := call(controller, "enqueue", jsutil.MakeUint8Array(chunk)); err != nil {
+					return err
+				}
+				for controller.Get("desiredSize").Int() <= 0 {
+					select {
+					case <-pulled:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				return nil
+			})
+			switch {
+			case ctx.Err() != nil:
+				// The consumer canceled the stream, which is closed already.
+			case status.Get("error").IsUndefined():
+				_, _ = call(controller, "close")
+			default:
+				// The stream may be errored already, by a failed enqueue.
+				_, _ = call(controller, "error", js.Global().Get("Error").New(status.Get("error")))
+			}
+			resolve.Invoke(status)
+		}()
+		return nil
+	})
+	return js.ValueOf(map[string]any{
+		"stream": stream,
+		"status": js.Global().Get("Promise").New(executor),
+	})
+}
+
+// streamTemplate executes the template of the processTemplate arguments args, and
+// passes the output to flush in chunks of at most chunkSize bytes. It returns the
+// status of the execution.
+func streamTemplate(ctx context.Context, args []js.Value, chunkSize js.Value, flush func(chunk []byte) error) (status js.Value) {
+	w := &chunkWriter{flush: flush}
+	defer func() {
+		if r := recover(); r != nil {
+			status = ActionProcessTemplate.ErrorResponse("recovered from panic: " + fmt.Sprint(r))
+		}
+		status.Set("bytes", w.n)
+	}()
+
+	size := DefaultChunkSize
+	if chunkSize.Truthy() {
+		if size = chunkSize.Int(); size <= 0 {
+			return ActionProcessTemplate.ErrorResponse("chunkSize must be positive")
+		}
+	}
+	req, err := renderRequest(args[0], args[1], args[2], args[3])
+	if err != nil {
+		return ActionProcessTemplate.ErrorResponse(err.Error())
+	}
+	if req == nil {
+		return js.ValueOf(map[string]any{"action": ActionProcessTemplate.String()})
+	}
+
+	w.buf = make([]byte, 0, size)
+	err = defaultEngine().RenderTo(ctx, w, req)
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return withTrace(withMetrics(ActionProcessTemplate.DiagnosticsResponse(err.Error(), diagnostic.FromError(err, req.Sources())), req.Metrics), req.Trace)
+	}
+	return withTrace(withMetrics(js.ValueOf(map[string]any{"action": ActionProcessTemplate.String()}), req.Metrics), req.Trace)
+}
+
+// chunkWriter is an [io.Writer] that passes what is written to flush in chunks of
+// cap(buf) bytes.
+type chunkWriter struct {
+	buf   []byte
+	flush func(chunk []byte) error
+	n     int // Number of bytes flushed without error.
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		k := min(cap(w.buf)-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:k]...)
+		p, n = p[k:], n+k
+		if len(w.buf) == cap(w.buf) {
+			if err := w.Flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush passes the buffered bytes, if any, to flush.
+func (w *chunkWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.flush(w.buf)
+	if err == nil {
+		w.n += len(w.buf)
+	}
+	w.buf = w.buf[:0]
+	return err
+}
+
+// invoke calls fn with args, and returns an exception it throws as an error.
+func invoke(fn js.Value, args ...any) (result js.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			jsErr, ok := r.(js.Error)
+			if !ok {
+				panic(r)
+			}
+			err = jsErr
+		}
+	}()
+	return fn.Invoke(args...), nil
+}
+
+// call calls the method m of v with args, and returns an exception it throws as an
+// error.
+func call(v js.Value, m string, args ...any) (js.Value, error) {
+	return invoke(v.Get(m).Call("bind", v), args...)
+}
+
+// awaitThenable waits for v to settle if it is a promise, or any other thenable, and
+// returns the reason it is rejected as an error. It stops waiting once ctx is done.
+func awaitThenable(ctx context.Context, v js.Value) error {
+	if v.Type() != js.TypeObject || v.Get("then").Type() != js.TypeFunction {
+		return nil
+	}
+	settled := make(chan error, 1)
+	var onFulfilled, onRejected js.Func
+	settle := func(err error) {
+		onFulfilled.Release()
+		onRejected.Release()
+		settled <- err
+	}
+	onFulfilled = js.FuncOf(func(this js.Value, args []js.Value) any {
+		settle(nil)
+		return nil
+	})
+	onRejected = js.FuncOf(func(this js.Value, args []js.Value) any {
+		reason := js.Undefined()
+		if len(args) > 0 {
+			reason = args[0]
+		}
+		settle(js.Error{Value: reason})
+		return nil
+	})
+
+	// The callbacks are released once v settles, which may be after ctx is done.
+	v.Call("then", onFulfilled, onRejected)
+	select {
+	case err := <-settled:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,203 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"context"
+	"syscall/js"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectChunks returns a chunk callback that appends the chunks it is called with to
+// chunks, and the value returned by result.
+func collectChunks(chunks *[]string, result func() any) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		b, _ := jsutil.CopyUint8Array(testutil.Ptr(args[0]))
+		*chunks = append(*chunks, string(b))
+		return result()
+	})
+}
+
+func Test_processTemplateStream(t *testing.T) {
+	helloArgs := []js.Value{
+		jsutil.MakeUint8Array([]byte("Hello, {{.}}!")),
+		jsutil.MakeUint8Array([]byte(`"World"`)),
+		js.ValueOf("json"),
+		js.Undefined(),
+	}
+	resolved := func() any { return js.Global().Get("Promise").Call("resolve") }
+
+	tests := []struct {
+		name     string
+		args     []js.Value
+		result   func() any
+		expected []string
+		bytes    int
+		errorMsg string
+	}{
+		{
+			name:     "Chunks",
+			args:     append(helloArgs[:4:4], js.Undefined(), js.ValueOf(4)),
+			result:   func() any { return nil },
+			expected: []string{"Hell", "o, W", "orld", "!"},
+			bytes:    13,
+		},
+		{
+			name:     "DefaultChunkSize",
+			args:     append(helloArgs[:4:4], js.Undefined()),
+			result:   func() any { return nil },
+			expected: []string{"Hello, World!"},
+			bytes:    13,
+		},
+		{
+			name:     "BackPressure",
+			args:     append(helloArgs[:4:4], js.Undefined(), js.ValueOf(8)),
+			result:   resolved,
+			expected: []string{"Hello, W", "orld!"},
+			bytes:    13,
+		},
+		{
+			name: "Rejected",
+			args: append(helloArgs[:4:4], js.Undefined(), js.ValueOf(8)),
+			result: func() any {
+				return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New("consumer gone"))
+			},
+			expected: []string{"Hello, W"},
+			bytes:    0,
+			errorMsg: "consumer gone",
+		},
+		{
+			name: "ExecuteError",
+			args: []js.Value{
+				jsutil.MakeUint8Array([]byte("{{range .}}{{.}}{{end}}{{.x}}")),
+				jsutil.MakeUint8Array([]byte(`[1, 2, 3]`)),
+				js.ValueOf("json"),
+				js.Undefined(),
+				js.Undefined(),
+				js.ValueOf(2),
+			},
+			result:   func() any { return nil },
+			expected: []string{"12", "3"},
+			bytes:    3,
+			errorMsg: "error executing template",
+		},
+		{
+			name: "RejectedLater",
+			args: append(helloArgs[:4:4], js.Undefined(), js.ValueOf(8)),
+			result: func() func() any {
+				calls := 0
+				return func() any {
+					if calls++; calls == 1 {
+						return nil
+					}
+					return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New("consumer gone"))
+				}
+			}(),
+			expected: []string{"Hello, W", "orld!"},
+			bytes:    8,
+			errorMsg: "consumer gone",
+		},
+		{
+			name:     "ChunkSizeError",
+			args:     append(helloArgs[:4:4], js.Undefined(), js.ValueOf(-1)),
+			result:   func() any { return nil },
+			errorMsg: "chunkSize must be positive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chunks []string
+			onChunk := collectChunks(&chunks, tt.result)
+			defer onChunk.Release()
+			args := append([]js.Value{}, tt.args...)
+			args[4] = onChunk.Value
+
+			status := processTemplateStream(context.Background(), args).(js.Value)
+			assert.Equal(t, tt.expected, chunks)
+			assert.Equal(t, tt.bytes, status.Get("bytes").Int())
+			if tt.errorMsg != "" {
+				assert.Contains(t, status.Get("error").String(), tt.errorMsg)
+			} else {
+				assert.True(t, status.Get("error").IsUndefined())
+			}
+		})
+	}
+}
+
+func Test_processTemplateStream_argumentError(t *testing.T) {
+	status := processTemplateStream(context.Background(), nil).(js.Value)
+	assert.Equal(t, "expected 5 to 6 arguments, got 0", status.Get("error").String())
+
+	status = processTemplateStream(context.Background(), []js.Value{
+		js.Undefined(), js.Undefined(), js.Undefined(), js.Undefined(), js.ValueOf("not a function"),
+	}).(js.Value)
+	assert.Equal(t, "onChunk must be a function", status.Get("error").String())
+}
+
+func Test_processTemplateReadable(t *testing.T) {
+	t.Run("Read", func(t *testing.T) {
+		result := processTemplateReadable(js.Undefined(), []js.Value{
+			jsutil.MakeUint8Array([]byte("{{range .}}{{.}},{{end}}")),
+			jsutil.MakeUint8Array([]byte(`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10]`)),
+			js.ValueOf("json"),
+			js.Undefined(),
+			js.ValueOf(3),
+		}).(js.Value)
+		text := await(t, js.Global().Get("Response").New(result.Get("stream")).Call("text"))
+		assert.Equal(t, "1,2,3,4,5,6,7,8,9,10,", text.String())
+		status := await(t, result.Get("status"))
+		assert.True(t, status.Get("error").IsUndefined())
+		assert.Equal(t, 21, status.Get("bytes").Int())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		result := processTemplateReadable(js.Undefined(), []js.Value{
+			jsutil.MakeUint8Array([]byte("{{.x}}")),
+			jsutil.MakeUint8Array([]byte(`[]`)),
+			js.ValueOf("json"),
+		}).(js.Value)
+		status := await(t, result.Get("status"))
+		assert.Contains(t, status.Get("error").String(), "error executing template")
+		require.Equal(t, 1, status.Get("diagnostics").Length())
+
+		errored := make(chan js.Value, 1)
+		onRejected := js.FuncOf(func(this js.Value, args []js.Value) any {
+			errored <- args[0]
+			return nil
+		})
+		defer onRejected.Release()
+		result.Get("stream").Call("getReader").Call("read").Call("catch", onRejected)
+		assert.Equal(t, status.Get("error").String(), (<-errored).Get("message").String())
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		result := processTemplateReadable(js.Undefined(), endlessArgs()).(js.Value)
+		result.Get("stream").Call("cancel")
+		status := await(t, result.Get("status"))
+		assert.Contains(t, status.Get("error").String(), "context canceled")
+	})
+
+	t.Run("ArgumentError", func(t *testing.T) {
+		result := processTemplateReadable(js.Undefined(), nil).(js.Value)
+		assert.Equal(t, "expected 3 to 5 arguments, got 0", result.Get("error").String())
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		readableStream := js.Global().Get("ReadableStream")
+		js.Global().Set("ReadableStream", js.Undefined())
+		defer js.Global().Set("ReadableStream", readableStream)
+
+		result := processTemplateReadable(js.Undefined(), []js.Value{
+			jsutil.MakeUint8Array([]byte("Hello")),
+			jsutil.MakeUint8Array([]byte(`{}`)),
+			js.ValueOf("json"),
+		}).(js.Value)
+		assert.Contains(t, result.Get("error").String(), "recovered from panic")
+	})
+}
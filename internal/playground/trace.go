@@ -0,0 +1,45 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"syscall/js"
+
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// newTrace returns the trace to fill in for a call with the options optionsJS, or nil
+// if the options do not ask for a trace.
+func newTrace(optionsJS js.Value) *tmpl.Trace {
+	if optionsJS.Type() == js.TypeObject && optionsJS.Get("trace").Truthy() {
+		return new(tmpl.Trace)
+	}
+	return nil
+}
+
+// withTrace adds trace to the response resp, unless trace is nil. It returns resp.
+func withTrace(resp js.Value, trace *tmpl.Trace) js.Value {
+	if trace == nil {
+		return resp
+	}
+	events := make([]any, len(trace.Events))
+	for i, e := range trace.Events {
+		events[i] = map[string]any{
+			"kind":     string(e.Kind),
+			"pipeline": e.Pipeline,
+			"template": e.Template,
+			"line":     e.Line,
+			"column":   e.Column,
+			"offset":   e.Offset,
+			"dot":      e.Dot,
+			"value":    e.Value,
+			"type":     e.Type,
+		}
+	}
+	resp.Set("trace", map[string]any{
+		"events":    events,
+		"truncated": trace.Truncated,
+	})
+	return resp
+}
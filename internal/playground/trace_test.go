@@ -0,0 +1,52 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playground
+
+import (
+	"context"
+	"syscall/js"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_trace(t *testing.T) {
+	args := func(options any) []js.Value {
+		return []js.Value{
+			jsutil.MakeUint8Array([]byte("{{range .}}{{.}}{{end}}")),
+			jsutil.MakeUint8Array([]byte(`["a", "b"]`)),
+			js.ValueOf("json"),
+			js.ValueOf(options),
+		}
+	}
+
+	t.Run("ProcessTemplate", func(t *testing.T) {
+		result := processTemplate(js.Undefined(), args(map[string]any{"trace": true})).(js.Value)
+		require.True(t, result.Get("error").IsUndefined())
+		trace := result.Get("trace")
+		assert.False(t, trace.Get("truncated").Bool())
+		events := trace.Get("events")
+		require.Equal(t, 3, events.Length())
+		assert.JSONEq(t,
+			`{"kind":"range","pipeline":".","template":"template","line":1,"column":9,"offset":8,"dot":"[a b]","value":"[a b]","type":"[]interface {}"}`,
+			jsonObject.Call("stringify", events.Index(0)).String())
+		assert.Equal(t, "b", events.Index(2).Get("value").String())
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		var chunks []string
+		onChunk := collectChunks(&chunks, func() any { return nil })
+		defer onChunk.Release()
+		status := processTemplateStream(context.Background(), append(args(map[string]any{"trace": true}), onChunk.Value)).(js.Value)
+		assert.Equal(t, []string{"ab"}, chunks)
+		assert.Equal(t, 3, status.Get("trace").Get("events").Length())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		result := processTemplate(js.Undefined(), args(js.Undefined())).(js.Value)
+		assert.True(t, result.Get("trace").IsUndefined())
+	})
+}
@@ -4,12 +4,13 @@
 package playground
 
 import (
-	"bytes"
 	"fmt"
 	"strconv"
 	"syscall/js"
 
 	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/engine"
 	"github.com/bartventer/go-template-playground/internal/jsutil"
 )
 
@@ -17,7 +18,8 @@ import (
 //
 // This function takes a Uint8Array as input data and transforms it from a specified format to another format.
 // It supports optional encoder options for customizing the transformation process. If the target format is not provided,
-// it defaults to the source format. The function ensures proper error handling and recovers from any panics that may occur.
+// it defaults to the source format. YAML and TOML data reformatted in its own format keeps its comments and layout.
+// The function ensures proper error handling and recovers from any panics that may occur.
 //
 // Parameters:
 //   - this: The JavaScript value representing the current context (not used).
@@ -32,10 +34,29 @@ import (
 //
 // TypeScript signature:
 //
+//	interface CSVOptions {
+//	   /** Field delimiter, a single character; defaults to "," for csv and "\t" for tsv. */
+//	   delimiter?: string;
+//	   /** Whether the first record is data rather than column names. */
+//	   noHeader?: boolean;
+//	   /** Whether to decode integers, floats and booleans as such rather than as strings. */
+//	   inferTypes?: boolean;
+//	   /** Whether to accept bare quotes in fields. */
+//	   lazyQuotes?: boolean;
+//	   /** Whether to quote every encoded field. */
+//	   quoteAll?: boolean;
+//	}
+//
 //	interface EncoderOptions {
 //	   insertSpaces?: number;
 //	   indentSize?: number;
 //	   noIndent?: boolean;
+//	   /** Whether to encode a top-level array in yaml as a stream of "---"-separated documents. */
+//	   yamlStream?: boolean;
+//	   /** Settings of the csv and tsv formats, for both decoding and encoding. */
+//	   csv?: CSVOptions;
+//	   /** Whether to add the metrics of the call to the result; see processTemplate. */
+//	   metrics?: boolean;
 //	}
 //
 //	declare function transformData(
@@ -47,7 +68,7 @@ import (
 //	   nextFormat?: Format, // Argument 2
 //	   /** Optional: Encoder options. */
 //	   options?: EncoderOptions, // Argument 3
-//	 ): { action: "transformData"; data: Uint8Array } | { action: "transformData"; error: string };
+//	 ): ({ action: "transformData"; data: Uint8Array } | { action: "transformData"; error: string; diagnostics?: Diagnostic[] }) & { metrics?: Metrics };
 func transformData(this js.Value, p []js.Value) (result interface{}) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -63,11 +84,11 @@ func transformData(this js.Value, p []js.Value) (result interface{}) {
 	dataView, prevFormat := p[0], p[1]
 
 	// Optional arguments.
-	var nextFormat js.Value
+	var nextFormat, optionsJS js.Value
 	var options *codec.EncoderOptions
 	switch len(p) {
 	case 4:
-		if optionsJS := p[3]; optionsJS.Type() != js.TypeUndefined {
+		if optionsJS = p[3]; optionsJS.Type() != js.TypeUndefined {
 			options = new(codec.EncoderOptions)
 			if err := options.UnmarshalJS(jsutil.JSValueWrapper{Value: optionsJS}); err != nil {
 				return ActionTransformData.ErrorResponse(err.Error())
@@ -84,41 +105,17 @@ func transformData(this js.Value, p []js.Value) (result interface{}) {
 	}
 
 	dataBytes, _ := jsutil.CopyUint8Array(&dataView)
-	resultBytes, err := transformDataBytes(
-		dataBytes,
-		codec.Format(prevFormat.String()),
-		codec.Format(nextFormat.String()),
-		options,
-	)
-	if err != nil {
-		return ActionTransformData.ErrorResponse(err.Error())
-	}
-
-	return ActionTransformData.SuccessResponse(resultBytes)
-}
-
-func transformDataBytes(data []byte, prevFormat, nextFormat codec.Format, options *codec.EncoderOptions) ([]byte, error) {
-	initPools()
-	dataReader := dataReaderPool.Get().(*bytes.Reader)
-	dataReader.Reset(data)
-	defer dataReaderPool.Put(dataReader)
-
-	decoder := codec.NewDecoder(dataReader, prevFormat)
-
-	var intermediateValue interface{}
-	if err := decoder.Decode(&intermediateValue); err != nil {
-		return nil, fmt.Errorf("error decoding data from format %s: %w", prevFormat, err)
+	req := &engine.TransformRequest{
+		Data:    dataBytes,
+		From:    codec.Format(prevFormat.String()),
+		To:      codec.Format(nextFormat.String()),
+		Options: options,
+		Metrics: newMetrics(optionsJS),
 	}
-
-	// Encode the intermediate value into the target format.
-	dataBuf := dataBufPool.Get().(*bytes.Buffer)
-	defer dataBufPool.Put(dataBuf)
-	dataBuf.Reset()
-
-	encoder := codec.NewEncoder(dataBuf, nextFormat, options)
-	if err := encoder.Encode(intermediateValue); err != nil {
-		return nil, fmt.Errorf("error encoding data to format %s: %w", nextFormat, err)
+	resp, err := defaultEngine().Transform(req)
+	if err != nil {
+		return withMetrics(ActionTransformData.DiagnosticsResponse(err.Error(), diagnostic.FromError(err, req.Sources())), req.Metrics)
 	}
 
-	return dataBuf.Bytes(), nil
+	return withMetrics(ActionTransformData.SuccessResponse(resp.Output), req.Metrics)
 }
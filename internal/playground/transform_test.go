@@ -9,6 +9,7 @@ import (
 
 	"github.com/bartventer/go-template-playground/internal/jsutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var transformTestCases = []struct {
@@ -111,3 +112,20 @@ func Test_transformData(t *testing.T) {
 		})
 	}
 }
+
+func Test_transformData_diagnostics(t *testing.T) {
+	result := transformData(js.Value{}, []js.Value{
+		jsutil.MakeUint8Array([]byte("key = \"value\"\nother = \n")),
+		js.ValueOf("toml"),
+		js.ValueOf("json"),
+	}).(js.Value)
+
+	diagnostics := result.Get("diagnostics")
+	require.Equal(t, 1, diagnostics.Length())
+	d := diagnostics.Index(0)
+	assert.Equal(t, "decode", d.Get("stage").String())
+	assert.True(t, d.Get("template").IsUndefined())
+	assert.Equal(t, 2, d.Get("line").Int())
+	assert.Equal(t, 9, d.Get("column").Int())
+	assert.Equal(t, 22, d.Get("offset").Int())
+}
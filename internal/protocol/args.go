@@ -0,0 +1,126 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/bartventer/go-template-playground/internal/util"
+)
+
+// Args holds the arguments of a call, named after the arguments of the playground
+// functions.
+type Args struct {
+	Templates  tmpl.Files    // Template sources, by name.
+	Template   string        // Source of a single, unnamed template; used if Templates is nil.
+	Data       []byte        // Context data, or data to transform.
+	Format     codec.Format  // Format of the context data.
+	PrevFormat codec.Format  // Format of the data to transform.
+	NextFormat codec.Format  // Format to transform to.
+	Options    util.JSValuer // Template or encoder options; nil if not given.
+}
+
+// Request is a call of an action.
+type Request struct {
+	Version int             // Protocol version; zero selects [Version].
+	ID      json.RawMessage // Identifier echoed in the response, as JSON; nil if not given.
+	Action  Action
+	Args
+}
+
+// jsonRequest is the JSON encoding of a [Request].
+type jsonRequest struct {
+	Version    int             `json:"version"`
+	ID         json.RawMessage `json:"id"`
+	Action     Action          `json:"action"`
+	Template   json.RawMessage `json:"template"` // A string, or an object of strings by name.
+	Data       string          `json:"data"`
+	Format     codec.Format    `json:"format"`
+	PrevFormat codec.Format    `json:"prevFormat"`
+	NextFormat codec.Format    `json:"nextFormat"`
+	Options    any             `json:"options"`
+}
+
+// UnmarshalJSON decodes a request. The template is either a string or an object
+// mapping names to sources, and the options an object. Unknown fields are an error.
+func (r *Request) UnmarshalJSON(b []byte) error {
+	var v jsonRequest
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	*r = Request{
+		Version: v.Version,
+		ID:      v.ID,
+		Action:  v.Action,
+		Args: Args{
+			Data:       []byte(v.Data),
+			Format:     v.Format,
+			PrevFormat: v.PrevFormat,
+			NextFormat: v.NextFormat,
+		},
+	}
+	if v.Options != nil {
+		r.Options = util.JSONValue{V: v.Options}
+	}
+	if len(v.Template) > 0 {
+		var named map[string]string
+		if err := json.Unmarshal(v.Template, &r.Template); err != nil {
+			if err := json.Unmarshal(v.Template, &named); err != nil {
+				return errors.New("template must be a string or an object of strings")
+			}
+			r.Templates = make(tmpl.Files, len(named))
+			for name, src := range named {
+				r.Templates[name] = []byte(src)
+			}
+		}
+	}
+	return nil
+}
+
+// files returns the template sources of a. A single template is named name.
+func (a *Args) files(name string) tmpl.Files {
+	if a.Templates != nil {
+		return a.Templates
+	}
+	return tmpl.Files{name: []byte(a.Template)}
+}
+
+// templateOptions returns the template options of a.
+func (a *Args) templateOptions() (tmpl.Options, error) {
+	var options tmpl.Options
+	if a.Options != nil {
+		if err := options.UnmarshalJS(a.Options); err != nil {
+			return options, fmt.Errorf("invalid options: %w", err)
+		}
+	}
+	return options, nil
+}
+
+// decoderOptions returns the decoder options of a, or nil if none were given.
+func (a *Args) decoderOptions() (*codec.DecoderOptions, error) {
+	if a.Options == nil {
+		return nil, nil
+	}
+	options := new(codec.DecoderOptions)
+	if err := options.UnmarshalJS(a.Options); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	return options, nil
+}
+
+// encoderOptions returns the encoder options of a, or nil if none were given.
+func (a *Args) encoderOptions() (*codec.EncoderOptions, error) {
+	if a.Options == nil {
+		return nil, nil
+	}
+	options := new(codec.EncoderOptions)
+	if err := options.UnmarshalJS(a.Options); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	return options, nil
+}
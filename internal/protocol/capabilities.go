@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"cmp"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// Capabilities describes what the module supports. It is the data of the response to
+// an [ActionCapabilities] request.
+type Capabilities struct {
+	ModuleVersion  string              `json:"moduleVersion"` // Version of the Go module, or "(devel)".
+	GoVersion      string              `json:"goVersion"`
+	Protocol       int                 `json:"protocol"` // Protocol version.
+	Actions        []Action            `json:"actions"`
+	Formats        []codec.Format      `json:"formats"`
+	EncoderOptions EncoderCapabilities `json:"encoderOptions"`
+	Engines        []tmpl.Engine       `json:"engines"`
+	MissingKeys    []tmpl.MissingKey   `json:"missingKeys"`
+	Functions      []string            `json:"functions"` // Names of the template functions, sorted.
+	Limits         LimitsCapabilities  `json:"limits"`
+}
+
+// EncoderCapabilities describes the encoder options.
+type EncoderCapabilities struct {
+	Options           []string `json:"options"` // Names of the options.
+	DefaultTabSize    int      `json:"defaultTabSize"`
+	DefaultIndentSize int      `json:"defaultIndentSize"` // Default size when indenting with spaces.
+	MaxIndentSize     int      `json:"maxIndentSize"`
+}
+
+// LimitsCapabilities describes the default execution limits.
+type LimitsCapabilities struct {
+	Timeout        int64 `json:"timeout"` // In milliseconds.
+	MaxOutputBytes int   `json:"maxOutputBytes"`
+	MaxSteps       int   `json:"maxSteps"`
+	MaxDepth       int   `json:"maxDepth"`
+}
+
+// capabilities returns the capabilities of the module, with the functions of e.
+func capabilities(e *engine.Engine) *Capabilities {
+	moduleVersion := "(devel)"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		moduleVersion = cmp.Or(info.Main.Version, moduleVersion)
+	}
+
+	return &Capabilities{
+		ModuleVersion: moduleVersion,
+		GoVersion:     runtime.Version(),
+		Protocol:      Version,
+		Actions:       Actions(),
+		Formats:       codec.Formats(),
+		EncoderOptions: EncoderCapabilities{
+			Options:           []string{"insertSpaces", "indentSize", "noIndent", "yamlStream", "csv"},
+			DefaultTabSize:    codec.DefaultTabSize,
+			DefaultIndentSize: codec.DefaultIndentSize,
+			MaxIndentSize:     codec.MaxIndentSize,
+		},
+		Engines:     tmpl.Engines(),
+		MissingKeys: tmpl.MissingKeys(),
+		Functions:   e.FuncNames(),
+		Limits: LimitsCapabilities{
+			Timeout:        tmpl.DefaultTimeout.Milliseconds(),
+			MaxOutputBytes: tmpl.DefaultMaxOutputBytes,
+			MaxSteps:       tmpl.DefaultMaxSteps,
+			MaxDepth:       tmpl.DefaultMaxDepth,
+		},
+	}
+}
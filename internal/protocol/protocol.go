@@ -0,0 +1,202 @@
+// Package protocol implements the versioned request/response protocol of the
+// playground, shared by the dispatch function of the WebAssembly module, the HTTP
+// server and the WASI command.
+//
+// A request carries the protocol version, an optional ID that is echoed in the
+// response, so that callers can match out of order replies, the name of an action, and
+// its arguments under the names of the arguments of the playground functions. A
+// response has the same shape as the objects returned by the playground functions,
+// except that the output of the render and transform actions is a string, and the
+// reports of the analyze and check actions are values rather than JSON encoded bytes.
+//
+// Within a version, new actions, arguments and response fields may be added, but
+// existing ones keep their meaning.
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/engine"
+)
+
+// Version is the current protocol version.
+const Version = 1
+
+// Action is the name of an action.
+type Action string
+
+// Supported actions. Except for [ActionCapabilities], they are named after the
+// playground functions.
+const (
+	ActionProcessTemplate Action = "ProcessTemplate"
+	ActionTransformData   Action = "TransformData"
+	ActionAnalyzeTemplate Action = "AnalyzeTemplate"
+	ActionCheckTemplate   Action = "CheckTemplate"
+	ActionCapabilities    Action = "Capabilities"
+)
+
+// Actions returns the supported actions.
+func Actions() []Action {
+	return []Action{
+		ActionProcessTemplate,
+		ActionTransformData,
+		ActionAnalyzeTemplate,
+		ActionCheckTemplate,
+		ActionCapabilities,
+	}
+}
+
+// Response is the result of a request.
+type Response struct {
+	Version     int                     `json:"version"`
+	ID          json.RawMessage         `json:"id,omitempty"`
+	Action      Action                  `json:"action"`
+	Data        any                     `json:"data,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+	Diagnostics []diagnostic.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// errorResponse returns the response reporting err, with the diagnostics describing it
+// if it is an error in sources.
+func errorResponse(action Action, err error, sources *diagnostic.Sources) *Response {
+	resp := &Response{Action: action, Error: err.Error()}
+	if sources != nil {
+		resp.Diagnostics = diagnostic.FromError(err, *sources)
+	}
+	return resp
+}
+
+// Dispatch calls the action of req. Errors in the request itself are reported without
+// diagnostics, and errors in the sources it holds with at least one.
+func Dispatch(e *engine.Engine, req *Request) *Response {
+	return DispatchContext(context.Background(), e, req)
+}
+
+// DispatchContext is like [Dispatch], but aborts template execution once ctx is done.
+func DispatchContext(ctx context.Context, e *engine.Engine, req *Request) *Response {
+	resp := dispatch(ctx, e, req)
+	resp.Version, resp.ID = Version, req.ID
+	return resp
+}
+
+func dispatch(ctx context.Context, e *engine.Engine, req *Request) *Response {
+	if req.Version != 0 && req.Version != Version {
+		return errorResponse(req.Action, fmt.Errorf("unsupported protocol version %d; the supported version is %d", req.Version, Version), nil)
+	}
+	args := &req.Args
+	switch req.Action {
+	case ActionProcessTemplate:
+		return render(ctx, e, args)
+	case ActionTransformData:
+		return transform(e, args)
+	case ActionAnalyzeTemplate:
+		return analyze(e, args)
+	case ActionCheckTemplate:
+		return check(e, args)
+	case ActionCapabilities:
+		return &Response{Action: ActionCapabilities, Data: capabilities(e)}
+	}
+	return errorResponse(req.Action, fmt.Errorf("unknown action %q", req.Action), nil)
+}
+
+func render(ctx context.Context, e *engine.Engine, args *Args) *Response {
+	const action = ActionProcessTemplate
+	options, err := args.templateOptions()
+	if err != nil {
+		return errorResponse(action, err, nil)
+	}
+	decoder, err := args.decoderOptions()
+	if err != nil {
+		return errorResponse(action, err, nil)
+	}
+	files := args.files(options.RootName())
+	if len(files) == 0 || (args.Templates == nil && args.Template == "") {
+		return &Response{Action: action, Data: ""}
+	}
+	req := &engine.RenderRequest{Templates: files, Data: args.Data, Format: args.Format, Options: options, Decoder: decoder}
+	resp, err := e.RenderContext(ctx, req)
+	if err != nil {
+		sources := req.Sources()
+		return errorResponse(action, err, &sources)
+	}
+	return &Response{Action: action, Data: string(resp.Output)}
+}
+
+func transform(e *engine.Engine, args *Args) *Response {
+	const action = ActionTransformData
+	options, err := args.encoderOptions()
+	if err != nil {
+		return errorResponse(action, err, nil)
+	}
+	req := &engine.TransformRequest{Data: args.Data, From: args.PrevFormat, To: args.NextFormat, Options: options}
+	resp, err := e.Transform(req)
+	if err != nil {
+		sources := req.Sources()
+		return errorResponse(action, err, &sources)
+	}
+	return &Response{Action: action, Data: string(resp.Output)}
+}
+
+func analyze(e *engine.Engine, args *Args) *Response {
+	const action = ActionAnalyzeTemplate
+	options, err := args.templateOptions()
+	if err != nil {
+		return errorResponse(action, err, nil)
+	}
+	req := &engine.AnalyzeRequest{Templates: args.files(options.RootName()), Options: options}
+	report, err := e.Analyze(req)
+	if err != nil {
+		sources := req.Sources()
+		return errorResponse(action, err, &sources)
+	}
+	return &Response{Action: action, Data: report}
+}
+
+func check(e *engine.Engine, args *Args) *Response {
+	const action = ActionCheckTemplate
+	options, err := args.templateOptions()
+	if err != nil {
+		return errorResponse(action, err, nil)
+	}
+	decoder, err := args.decoderOptions()
+	if err != nil {
+		return errorResponse(action, err, nil)
+	}
+	req := &engine.CheckRequest{Templates: args.files(options.RootName()), Data: args.Data, Format: args.Format, Options: options, Decoder: decoder}
+	resp, err := e.Check(req)
+	if err != nil {
+		sources := req.Sources()
+		return errorResponse(action, err, &sources)
+	}
+	diags := resp.Diagnostics
+	if diags == nil {
+		diags = []diagnostic.Diagnostic{}
+	}
+	return &Response{Action: action, Data: diags}
+}
+
+// ServeJSON reads a single [Request] from r, dispatches it and writes the response to
+// w. It returns the error reported in the response, if any.
+func ServeJSON(e *engine.Engine, r io.Reader, w io.Writer) error {
+	resp := serveJSON(e, r)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+func serveJSON(e *engine.Engine, r io.Reader) *Response {
+	var req Request
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return &Response{Version: Version, Error: "invalid request: " + err.Error()}
+	}
+	return Dispatch(e, &req)
+}
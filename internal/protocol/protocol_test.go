@@ -0,0 +1,170 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  string
+		expected string
+		errorMsg string
+	}{
+		{
+			name:     "Render",
+			request:  `{"version":1,"action": "ProcessTemplate", "template": {"a": "{{.n}}{{template \"b\"}}", "b": "!"}, "data": "n = 1", "format": "toml", "options": {"entry": "a"}}`,
+			expected: `{"version":1,"action":"ProcessTemplate","data":"1!"}`,
+		},
+		{
+			name:     "Transform",
+			request:  `{"version":1,"action": "TransformData", "data": "{\"a\": 1}", "prevFormat": "json", "nextFormat": "yaml"}`,
+			expected: `{"version":1,"action":"TransformData","data":"a: 1\n"}`,
+		},
+		{
+			name:     "RenderCSV",
+			request:  `{"version":1,"action": "ProcessTemplate", "template": "{{range .}}{{.name}}={{add .n 1}} {{end}}", "data": "name;n\na;1\nb;2", "format": "csv", "options": {"csv": {"delimiter": ";", "inferTypes": true}}}`,
+			expected: `{"version":1,"action":"ProcessTemplate","data":"a=2 b=3 "}`,
+		},
+		{
+			name:     "TransformCSV",
+			request:  `{"version":1,"action": "TransformData", "data": "[{\"a\": 1, \"b\": \"x\"}]", "prevFormat": "json", "nextFormat": "tsv", "options": {"csv": {"quoteAll": true}}}`,
+			expected: `{"version":1,"action":"TransformData","data":"\"a\"\t\"b\"\n\"1\"\t\"x\"\n"}`,
+		},
+		{
+			name:     "RenderYAMLStream",
+			request:  `{"version":1,"action": "ProcessTemplate", "template": "{{len .}}{{range .}} {{.kind}}{{end}}", "data": "kind: Service\n---\nkind: Deployment\n", "format": "yaml"}`,
+			expected: `{"version":1,"action":"ProcessTemplate","data":"2 Service Deployment"}`,
+		},
+		{
+			name:     "TransformYAMLStream",
+			request:  `{"version":1,"action": "TransformData", "data": "[{\"a\": 1}, {\"b\": 2}]", "prevFormat": "json", "nextFormat": "yaml", "options": {"yamlStream": true}}`,
+			expected: `{"version":1,"action":"TransformData","data":"a: 1\n---\nb: 2\n"}`,
+		},
+		{
+			name:     "TransformKeepsOrder",
+			request:  `{"version":1,"action": "TransformData", "data": "z: 1\na:\n  y: 2\n  b: 3\n", "prevFormat": "yaml", "nextFormat": "json", "options": {"noIndent": true}}`,
+			expected: `{"version":1,"action":"TransformData","data":"{\"z\":1,\"a\":{\"y\":2,\"b\":3}}\n"}`,
+		},
+		{
+			name:     "RenderOrderedMap",
+			request:  `{"version":1,"action": "ProcessTemplate", "template": "{{range $k, $v := .}}{{$k}}={{$v}} {{end}}{{.z}}", "data": "{\"z\": 1, \"a\": 2}", "format": "json"}`,
+			expected: `{"version":1,"action":"ProcessTemplate","data":"a=2 z=1 1"}`,
+		},
+		{
+			name:     "Check",
+			request:  `{"version":1,"action": "CheckTemplate", "template": "{{.n}}", "data": "{\"n\": 1}", "format": "json"}`,
+			expected: `{"version":1,"action":"CheckTemplate","data":[]}`,
+		},
+		{
+			name:     "CheckCSV",
+			request:  `{"version":1,"action": "CheckTemplate", "template": "{{range .}}{{index . 0}}{{.x}}{{end}}", "data": "a,1\nb,2\n", "format": "csv", "options": {"csv": {"noHeader": true}}}`,
+			expected: `{"version":1,"action":"CheckTemplate","data":[{"stage":"check","severity":"warning","code":"ScalarField","template":"template","line":1,"column":27,"offset":26,"message":".x: can't evaluate field x in list"}]}`,
+		},
+		{
+			name:     "ID",
+			request:  `{"version": 1, "id": "req-1", "action": "TransformData", "data": "1", "prevFormat": "json"}`,
+			expected: `{"version":1,"id":"req-1","action":"TransformData","data":"1\n"}`,
+		},
+		{
+			name:     "UnsupportedVersion",
+			request:  `{"version": 2, "id": 3, "action": "TransformData"}`,
+			expected: `{"version":1,"id":3,"action":"TransformData","error":"unsupported protocol version 2; the supported version is 1"}`,
+			errorMsg: "unsupported protocol version 2",
+		},
+		{
+			name:     "ExecuteError",
+			request:  `{"version":1,"action": "ProcessTemplate", "template": "{{fail}}", "data": "{}", "format": "json"}`,
+			expected: `{"version":1,"action":"ProcessTemplate","error":"error parsing template: template: template:1: function \"fail\" not defined","diagnostics":[{"stage":"parse","severity":"error","template":"template","line":1,"column":0,"offset":0,"message":"function \"fail\" not defined"}]}`,
+			errorMsg: "error parsing template",
+		},
+		{
+			name:     "InvalidOptions",
+			request:  `{"version":1,"action": "TransformData", "data": "{}", "prevFormat": "json", "options": {"indentSize": "2"}}`,
+			expected: `{"version":1,"action":"TransformData","error":"invalid options: json: call of JSONValue.Int on string"}`,
+			errorMsg: "invalid options",
+		},
+		{
+			name:     "AnalyzeDelims",
+			request:  `{"version":1,"action": "AnalyzeTemplate", "template": "[[.x]]", "options": {"delims": ["[[", "]]"]}}`,
+			expected: `{"version":1,"action":"AnalyzeTemplate","data":{"fields":[{"name":".x","template":"template","line":1,"column":3,"offset":2}],"variables":[],"functions":[],"templates":[],"defines":[]}}`,
+		},
+		{
+			name:     "InvalidDelims",
+			request:  `{"version":1,"action": "ProcessTemplate", "template": "[[.]]", "data": "1", "format": "json", "options": {"delims": ["[["]}}`,
+			expected: `{"version":1,"action":"ProcessTemplate","error":"invalid options: delims must be an array of two non-empty strings"}`,
+			errorMsg: "invalid options",
+		},
+		{
+			name:     "UnknownAction",
+			request:  `{"version":1,"action": "Render"}`,
+			expected: `{"version":1,"action":"Render","error":"unknown action \"Render\""}`,
+			errorMsg: `unknown action "Render"`,
+		},
+		{
+			name:     "InvalidTemplate",
+			request:  `{"version":1,"action": "AnalyzeTemplate", "template": 1}`,
+			expected: `{"version":1,"action":"","error":"invalid request: template must be a string or an object of strings"}`,
+			errorMsg: "invalid request",
+		},
+		{
+			name:     "UnknownField",
+			request:  `{"version":1,"action": "AnalyzeTemplate", "templates": {}}`,
+			expected: `{"version":1,"action":"","error":"invalid request: json: unknown field \"templates\""}`,
+			errorMsg: "invalid request",
+		},
+	}
+	e := engine.New(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := ServeJSON(e, strings.NewReader(tt.request), &out)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.JSONEq(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestDispatch_emptyTemplate(t *testing.T) {
+	resp := Dispatch(engine.New(0), &Request{Action: ActionProcessTemplate, Args: Args{Data: []byte(`{`), Format: "json"}})
+	got, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":1,"action":"ProcessTemplate","data":""}`, string(got))
+}
+
+func TestDispatch_capabilities(t *testing.T) {
+	resp := Dispatch(engine.New(0), &Request{ID: json.RawMessage(`1`), Action: ActionCapabilities})
+	require.Empty(t, resp.Error)
+	assert.Equal(t, json.RawMessage(`1`), resp.ID)
+
+	c, ok := resp.Data.(*Capabilities)
+	require.True(t, ok)
+	assert.Equal(t, Version, c.Protocol)
+	assert.Equal(t, Actions(), c.Actions)
+	assert.Equal(t, []codec.Format{codec.FormatJSON, codec.FormatYAML, codec.FormatTOML, codec.FormatXML, codec.FormatCSV, codec.FormatTSV}, c.Formats)
+	assert.Equal(t, []tmpl.Engine{tmpl.EngineText, tmpl.EngineHTML}, c.Engines)
+	assert.Contains(t, c.Functions, "upper")
+	assert.True(t, slices.IsSorted(c.Functions))
+	assert.Equal(t, int64(5000), c.Limits.Timeout)
+
+	e := engine.New(0, engine.WithFuncs(template.FuncMap{"custom": func() string { return "" }}))
+	c = Dispatch(e, &Request{Action: ActionCapabilities}).Data.(*Capabilities)
+	assert.Contains(t, c.Functions, "custom", "functions added to the engine are listed")
+	assert.True(t, slices.IsSorted(c.Functions))
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/protocol"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+	"github.com/bartventer/go-template-playground/internal/util"
+)
+
+// readRequest reads a request from the body of r, which is either JSON or a multipart
+// form.
+func readRequest(r *http.Request, maxBytes int64) (*protocol.Request, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type: %w", err)
+	}
+	switch mediaType {
+	case "application/json":
+		var req protocol.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+		return &req, nil
+	case "multipart/form-data":
+		return readMultipart(r, maxBytes)
+	}
+	return nil, fmt.Errorf("unsupported content type %q; use application/json or multipart/form-data", mediaType)
+}
+
+// readMultipart reads a multipart form. Templates are sent as "template" parts; a
+// part with a file name is a named template, and a part without one is a single
+// template. The other fields are sent as parts of the same name, and the options and
+// ID as JSON.
+func readMultipart(r *http.Request, maxBytes int64) (*protocol.Request, error) {
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	form := r.MultipartForm
+	defer form.RemoveAll() //nolint:errcheck // Nothing is written to disk within maxBytes.
+
+	value := func(key string) string {
+		if values := form.Value[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	req := &protocol.Request{
+		ID: json.RawMessage(value("id")),
+		Args: protocol.Args{
+			Template:   value("template"),
+			Format:     codec.Format(value("format")),
+			PrevFormat: codec.Format(value("prevFormat")),
+			NextFormat: codec.Format(value("nextFormat")),
+		},
+	}
+	if version := value("version"); version != "" {
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %w", err)
+		}
+		req.Version = v
+	}
+	if len(req.ID) > 0 && !json.Valid(req.ID) {
+		return nil, errors.New("invalid id: not a JSON value")
+	}
+	for _, fh := range form.File["template"] {
+		src, err := readFormFile(fh)
+		if err != nil {
+			return nil, err
+		}
+		if req.Templates == nil {
+			req.Templates = make(tmpl.Files)
+		}
+		req.Templates[fh.Filename] = src
+	}
+
+	req.Data = []byte(value("data"))
+	if fhs := form.File["data"]; len(fhs) > 0 {
+		src, err := readFormFile(fhs[0])
+		if err != nil {
+			return nil, err
+		}
+		req.Data = src
+	}
+
+	if options := value("options"); options != "" {
+		var v any
+		if err := json.Unmarshal([]byte(options), &v); err != nil {
+			return nil, fmt.Errorf("invalid options: %w", err)
+		}
+		req.Options = util.JSONValue{V: v}
+	}
+	return req, nil
+}
+
+func readFormFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
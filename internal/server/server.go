@@ -0,0 +1,103 @@
+// Package server exposes the engine over HTTP, so that scripts and editor plugins can
+// use the playground without a browser.
+//
+// Each endpoint accepts a POST request whose body is either a JSON object, as read by
+// package protocol, or a multipart form, with fields named after the arguments of the
+// playground function it mirrors:
+//
+//	POST /render     processTemplate: template, data, format, options
+//	POST /transform  transformData:   data, prevFormat, nextFormat, options
+//	POST /analyze    analyzeTemplate: template
+//	POST /check      checkTemplate:   template, data, format, options
+//	GET /capabilities
+//
+// Requests may also carry the protocol version and an ID, which is echoed in the
+// response. The action is taken from the path.
+//
+// In JSON, template is either a string or an object mapping names to sources, and
+// options is an object. In a multipart form, each named template is a file part called
+// "template", and options is a JSON encoded value part.
+//
+// Responses have the same shape as those of the playground functions. The data of
+// render and transform responses is a string, and that of the other responses a JSON
+// value. Errors are reported with a 4xx status, an error message and, for
+// errors in the sources, the diagnostics describing them.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/protocol"
+)
+
+// DefaultMaxBodyBytes is the default maximum size of a request body.
+const DefaultMaxBodyBytes = 8 << 20
+
+// Options configures a server.
+type Options struct {
+	MaxBodyBytes int64 // Maximum size of a request body; defaults to DefaultMaxBodyBytes.
+}
+
+type server struct {
+	engine       *engine.Engine
+	maxBodyBytes int64
+}
+
+// New returns a handler serving the endpoints with e.
+func New(e *engine.Engine, options Options) http.Handler {
+	s := &server{engine: e, maxBodyBytes: options.MaxBodyBytes}
+	if s.maxBodyBytes <= 0 {
+		s.maxBodyBytes = DefaultMaxBodyBytes
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/render", s.handle(protocol.ActionProcessTemplate))
+	mux.Handle("/transform", s.handle(protocol.ActionTransformData))
+	mux.Handle("/analyze", s.handle(protocol.ActionAnalyzeTemplate))
+	mux.Handle("/check", s.handle(protocol.ActionCheckTemplate))
+	mux.Handle("GET /capabilities", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, protocol.Dispatch(s.engine, &protocol.Request{Action: protocol.ActionCapabilities}))
+	}))
+	return mux
+}
+
+// handle returns a handler that reads a request, dispatches it to action and writes
+// its response.
+func (s *server) handle(action protocol.Action) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, &protocol.Response{Version: protocol.Version, Action: action, Error: "method not allowed"})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxBodyBytes)
+		req, err := readRequest(r, s.maxBodyBytes)
+		if err != nil {
+			status := http.StatusBadRequest
+			if maxErr := new(http.MaxBytesError); errors.As(err, &maxErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeJSON(w, status, &protocol.Response{Version: protocol.Version, Action: action, Error: err.Error()})
+			return
+		}
+
+		req.Action = action
+		resp := protocol.DispatchContext(r.Context(), s.engine, req)
+		switch {
+		case resp.Error == "":
+			writeJSON(w, http.StatusOK, resp)
+		case len(resp.Diagnostics) > 0:
+			writeJSON(w, http.StatusUnprocessableEntity, resp)
+		default:
+			writeJSON(w, http.StatusBadRequest, resp)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// do sends a request to a test server and decodes the response.
+func do(t *testing.T, h http.Handler, req *http.Request) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+	return rec.Code, body
+}
+
+func newJSONRequest(path, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestServer_JSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   map[string]any
+	}{
+		{
+			name:       "Render",
+			path:       "/render",
+			body:       `{"template": "Hello, {{.Name}}!", "data": "{\"Name\": \"World\"}", "format": "json"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"version": 1.0, "action": "ProcessTemplate", "data": "Hello, World!"},
+		},
+		{
+			name: "RenderFiles",
+			path: "/render",
+			body: `{"template": {"a": "<{{template \"b\" .}}>", "b": "{{.}}"}, "data": "x: 1", "format": "yaml",
+				"options": {"entry": "a", "delims": ["{{", "}}"], "limits": {"maxSteps": 100}}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"version": 1.0, "action": "ProcessTemplate", "data": "<map[x:1]>"},
+		},
+		{
+			name:       "RenderEmpty",
+			path:       "/render",
+			body:       `{"template": "", "data": "{", "format": "json"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"version": 1.0, "action": "ProcessTemplate", "data": ""},
+		},
+		{
+			name:       "RenderParseError",
+			path:       "/render",
+			body:       `{"template": "{{.Name", "data": "{}", "format": "json"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody: map[string]any{
+				"version": 1.0,
+				"action":  "ProcessTemplate",
+				"error":   "error parsing template: template: template:1: unclosed action",
+				"diagnostics": []any{map[string]any{
+					"stage": "parse", "severity": "error", "template": "template",
+					"line": 1.0, "column": 0.0, "offset": 0.0, "message": "unclosed action",
+				}},
+			},
+		},
+		{
+			name:       "InvalidOptions",
+			path:       "/render",
+			body:       `{"template": "x", "data": "{}", "format": "json", "options": {"limits": {"maxSteps": "many"}}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   map[string]any{"version": 1.0, "action": "ProcessTemplate", "error": "invalid options: json: call of JSONValue.Int on string"},
+		},
+		{
+			name:       "Transform",
+			path:       "/transform",
+			body:       `{"data": "{\"a\": 1}", "prevFormat": "json", "nextFormat": "yaml"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"version": 1.0, "action": "TransformData", "data": "a: 1\n"},
+		},
+		{
+			name:       "TransformOptions",
+			path:       "/transform",
+			body:       `{"data": "{\"a\": 1}", "prevFormat": "json", "options": {"insertSpaces": true, "indentSize": 1}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"version": 1.0, "action": "TransformData", "data": "{\n \"a\": 1\n}\n"},
+		},
+		{
+			name:       "Check",
+			path:       "/check",
+			body:       `{"template": "{{.Name}}", "data": "{\"Name\": 1}", "format": "json"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"version": 1.0, "action": "CheckTemplate", "data": []any{}},
+		},
+		{
+			name:       "ID",
+			path:       "/transform",
+			body:       `{"version": 1, "id": {"seq": 7}, "action": "ignored", "data": "1", "prevFormat": "json"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"version": 1.0, "id": map[string]any{"seq": 7.0}, "action": "TransformData", "data": "1\n"},
+		},
+		{
+			name:       "UnsupportedVersion",
+			path:       "/transform",
+			body:       `{"version": 2, "id": "a"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody: map[string]any{
+				"version": 1.0, "id": "a", "action": "TransformData",
+				"error": "unsupported protocol version 2; the supported version is 1",
+			},
+		},
+		{
+			name:       "UnknownField",
+			path:       "/analyze",
+			body:       `{"templates": {}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   map[string]any{"version": 1.0, "action": "AnalyzeTemplate", "error": `invalid request body: json: unknown field "templates"`},
+		},
+	}
+	h := New(engine.New(0), Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(t, h, newJSONRequest(tt.path, tt.body))
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestServer_Analyze(t *testing.T) {
+	h := New(engine.New(0), Options{})
+	status, body := do(t, h, newJSONRequest("/analyze", `{"template": "{{.Name}}"}`))
+	require.Equal(t, http.StatusOK, status)
+	data, ok := body["data"].(map[string]any)
+	require.True(t, ok)
+	assert.Len(t, data["fields"], 1)
+}
+
+func TestServer_Capabilities(t *testing.T) {
+	h := New(engine.New(0), Options{})
+	status, body := do(t, h, httptest.NewRequest(http.MethodGet, "/capabilities", nil))
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Capabilities", body["action"])
+	data, ok := body["data"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, []any{"json", "yaml", "toml", "xml", "csv", "tsv"}, data["formats"])
+}
+
+func TestServer_Multipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, src := range map[string]string{"index.tmpl": `{{template "_b.tmpl" .}}!`, "_b.tmpl": `Hi {{.name}}`} {
+		fw, err := mw.CreateFormFile("template", name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(src))
+		require.NoError(t, err)
+	}
+	fw, err := mw.CreateFormFile("data", "values.toml")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(`name = "there"`))
+	require.NoError(t, err)
+	require.NoError(t, mw.WriteField("format", "toml"))
+	require.NoError(t, mw.WriteField("options", `{"entry": "index.tmpl"}`))
+	require.NoError(t, mw.WriteField("id", `42`))
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/render", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	status, body := do(t, New(engine.New(0), Options{}), req)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"version": 1.0, "id": 42.0, "action": "ProcessTemplate", "data": "Hi there!"}, body)
+}
+
+func TestServer_errors(t *testing.T) {
+	h := New(engine.New(0), Options{MaxBodyBytes: 16})
+
+	status, body := do(t, h, httptest.NewRequest(http.MethodGet, "/render", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+	assert.Equal(t, "method not allowed", body["error"])
+
+	status, body = do(t, h, newJSONRequest("/transform", `{"data": "0123456789abcdef"}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Contains(t, body["error"], "request body too large")
+
+	req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("x"))
+	req.Header.Set("Content-Type", "text/plain")
+	status, body = do(t, h, req)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body["error"], "unsupported content type")
+}
@@ -0,0 +1,115 @@
+package tmpl
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"strconv"
+	"sync"
+	"text/template"
+)
+
+// DefaultCacheSize is the number of template sets held by a [Cache] by default.
+const DefaultCacheSize = 32
+
+// Cache is a bounded cache of parsed template sets, keyed by a hash of their source
+// and parse options. When full, the least recently used set is evicted. It is safe
+// for concurrent use.
+type Cache struct {
+	mu      sync.Mutex // Guards entries and index; not held while parsing.
+	size    int
+	entries *list.List // Of *cacheEntry, most recently used first.
+	index   map[cacheKey]*list.Element
+	parse   func(Files, Options, template.FuncMap) (*Template, error)
+}
+
+type cacheKey [sha256.Size]byte
+
+// cacheEntry is a cached template set. The first caller to need it parses it, and
+// concurrent callers with the same key wait for that parse.
+type cacheEntry struct {
+	key  cacheKey
+	once sync.Once
+	set  *Template
+	err  error
+}
+
+// NewCache returns a cache holding up to size template sets. A size less than one
+// selects [DefaultCacheSize].
+func NewCache(size int) *Cache {
+	if size < 1 {
+		size = DefaultCacheSize
+	}
+	return &Cache{
+		size:    size,
+		entries: list.New(),
+		index:   make(map[cacheKey]*list.Element, size),
+		parse:   ParseFiles,
+	}
+}
+
+// Parse returns a clone of the template set parsed from files by [ParseFiles], which
+// is only called if the set is not cached. Parse errors are cached as well. The same
+// funcs must be given on every call, since they are not part of the key.
+func (c *Cache) Parse(files Files, options Options, funcs template.FuncMap) (*Template, error) {
+	entry := c.entry(cacheKeyOf(files, options))
+	entry.once.Do(func() {
+		entry.set, entry.err = c.parse(files, options, funcs)
+	})
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return entry.set.Clone()
+}
+
+// entry returns the entry of key, adding an entry to be parsed if there is none, and
+// marks it as the most recently used.
+func (c *Cache) entry(key cacheKey) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.index[key]; ok {
+		c.entries.MoveToFront(e)
+		return e.Value.(*cacheEntry)
+	}
+	entry := &cacheEntry{key: key}
+	c.index[key] = c.entries.PushFront(entry)
+	if c.entries.Len() > c.size {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.index, oldest.Value.(*cacheEntry).key)
+	}
+	return entry
+}
+
+// Len returns the number of cached template sets.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+// cacheKeyOf returns the hash of the files and of the options used by [ParseFiles].
+// Each string is length-prefixed, so that different inputs cannot share an encoding.
+func cacheKeyOf(files Files, options Options) cacheKey {
+	h := sha256.New()
+	writeString(h, string(options.Engine))
+	writeString(h, options.LeftDelim)
+	writeString(h, options.RightDelim)
+	writeString(h, string(options.MissingKey))
+	writeString(h, strconv.FormatBool(options.Trace))
+	for _, name := range files.Names() {
+		writeString(h, name)
+		writeString(h, string(files[name]))
+	}
+	var key cacheKey
+	h.Sum(key[:0])
+	return key
+}
+
+func writeString(h hash.Hash, s string) {
+	var n [binary.MaxVarintLen64]byte
+	h.Write(n[:binary.PutUvarint(n[:], uint64(len(s)))])
+	h.Write([]byte(s))
+}
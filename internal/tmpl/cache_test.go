@@ -0,0 +1,115 @@
+package tmpl
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Parse(t *testing.T) {
+	c := NewCache(2)
+	files := Files{"a": []byte(`{{define "b"}}b{{end}}a{{template "b"}}`)}
+
+	first, err := c.Parse(files, Options{}, nil)
+	require.NoError(t, err)
+	second, err := c.Parse(files, Options{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Len())
+	assert.NotSame(t, first.text, second.text, "each call returns a clone")
+
+	_, err = c.Parse(files, Options{LeftDelim: "[[", RightDelim: "]]"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Len(), "options are part of the key")
+
+	_, err = c.Parse(Files{"a": []byte(`{{`)}, Options{}, nil)
+	require.Error(t, err)
+	assert.Equal(t, 2, c.Len(), "least recently used set is evicted")
+	_, err = c.Parse(Files{"a": []byte(`{{`)}, Options{}, nil)
+	require.Error(t, err, "parse errors are cached")
+}
+
+func TestCache_Parse_HTML(t *testing.T) {
+	c := NewCache(0)
+	files := Files{"a": []byte(`<a href="{{.}}">`)}
+	for range 2 {
+		set, err := c.Parse(files, Options{Engine: EngineHTML}, nil)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, set.Execute(&buf, "javascript:x", Limits{}))
+		assert.Equal(t, `<a href="#ZgotmplZ">`, buf.String())
+	}
+}
+
+func TestCache_Parse_isolation(t *testing.T) {
+	c := NewCache(0)
+	set, err := c.Parse(Files{"a": []byte(`a`)}, Options{}, nil)
+	require.NoError(t, err)
+	_, err = set.text.New("b").Parse(`b`)
+	require.NoError(t, err)
+
+	set, err = c.Parse(Files{"a": []byte(`a`)}, Options{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, set.Lookup("b"), "changes to a clone do not leak into the cache")
+}
+
+func TestCache_Parse_concurrent(t *testing.T) {
+	c := NewCache(0)
+	var (
+		calls   atomic.Int32
+		started = make(chan struct{})
+		blocked = make(chan struct{})
+	)
+	c.parse = func(files Files, options Options, funcs template.FuncMap) (*Template, error) {
+		calls.Add(1)
+		if _, ok := files["slow"]; ok {
+			close(started)
+			<-blocked
+		}
+		return ParseFiles(files, options, funcs)
+	}
+
+	slow := make(chan error)
+	go func() {
+		_, err := c.Parse(Files{"slow": []byte(`s`)}, Options{}, nil)
+		slow <- err
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			set, err := c.Parse(Files{"a": []byte(`a`)}, Options{}, nil)
+			assert.NoError(t, err)
+			assert.NotNil(t, set)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, c.Len(), "other keys are parsed while a parse is in progress")
+
+	close(blocked)
+	require.NoError(t, <-slow)
+	assert.Equal(t, int32(2), calls.Load(), "each key is parsed once")
+}
+
+func TestCacheKeyOf(t *testing.T) {
+	assert.Equal(t,
+		cacheKeyOf(Files{"a": []byte("x"), "b": []byte("y")}, Options{}),
+		cacheKeyOf(Files{"b": []byte("y"), "a": []byte("x")}, Options{Entry: "a", Name: "n"}),
+	)
+	assert.NotEqual(t,
+		cacheKeyOf(Files{"ab": []byte("c")}, Options{}),
+		cacheKeyOf(Files{"a": []byte("bc")}, Options{}),
+	)
+	assert.NotEqual(t,
+		cacheKeyOf(Files{"a": nil}, Options{}),
+		cacheKeyOf(Files{"a": nil}, Options{MissingKey: MissingKeyError}),
+	)
+}
@@ -0,0 +1,58 @@
+package tmpl
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"text/template"
+	"time"
+
+	// Time zones are resolved in the browser, which has no zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/bartventer/go-template-playground/internal/util"
+)
+
+// Determinism fixes the results of the time and random template functions, so that
+// rendering the same template with the same data always produces the same output.
+type Determinism struct {
+	Now      time.Time      // Instant returned by now and date; zero uses the current time.
+	Location *time.Location // Time zone of now and date; nil uses [time.Local].
+	Seed     uint64         // Seed of the random source used by randInt.
+}
+
+// Funcs returns the template functions with now, date and randInt bound to the clock
+// and random source fixed by d. Each call returns functions with a freshly seeded source.
+func (d *Determinism) Funcs() template.FuncMap {
+	loc := cmp.Or(d.Location, time.Local)
+	clock := func() time.Time {
+		if d.Now.IsZero() {
+			return time.Now().In(loc)
+		}
+		return d.Now.In(loc)
+	}
+	return NewFuncs(WithClock(clock), WithRand(rand.New(rand.NewPCG(d.Seed, d.Seed))))
+}
+
+// Unmarshalls the javascript object into a Determinism struct. The instant is given
+// as an RFC 3339 string and the time zone as an IANA name, such as "Europe/Berlin".
+func (d *Determinism) UnmarshalJS(data util.JSValuer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	if data.Get("now").Truthy() {
+		if d.Now, err = time.Parse(time.RFC3339Nano, data.Get("now").String()); err != nil {
+			return err
+		}
+	}
+	if data.Get("timeZone").Truthy() {
+		if d.Location, err = time.LoadLocation(data.Get("timeZone").String()); err != nil {
+			return err
+		}
+	}
+	if data.Get("seed").Truthy() {
+		d.Seed = uint64(data.Get("seed").Int())
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package tmpl
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterminism_Funcs(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	d := &Determinism{
+		Now:      time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC),
+		Location: berlin,
+		Seed:     42,
+	}
+
+	render := func() string {
+		tpl := template.Must(template.New("t").Funcs(d.Funcs()).Parse(
+			`{{now.Format "2006-01-02T15:04:05Z07:00"}} {{date}} {{randInt 1000}} {{randInt 1000}}`))
+		var buf bytes.Buffer
+		require.NoError(t, tpl.Execute(&buf, nil))
+		return buf.String()
+	}
+
+	first := render()
+	assert.Regexp(t, `^2025-01-01T00:30:00\+01:00 2025-01-01 \d+ \d+$`, first)
+	assert.Equal(t, first, render(), "each call is seeded afresh")
+}
+
+func TestDeterminism_Funcs_currentTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	now := (&Determinism{Location: tokyo}).Funcs()["now"].(func() time.Time)()
+	assert.Equal(t, tokyo, now.Location())
+	assert.WithinDuration(t, time.Now(), now, time.Minute)
+}
+
+func TestNewFuncs(t *testing.T) {
+	fixed := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	funcs := NewFuncs(WithClock(func() time.Time { return fixed }))
+	assert.Equal(t, "2000-01-02", funcs["date"].(func() string)())
+	assert.Equal(t, fixed, funcs["now"].(func() time.Time)())
+	assert.NotNil(t, TemplateFuncs()["randInt"])
+	assert.Len(t, funcs, len(TemplateFuncs()))
+}
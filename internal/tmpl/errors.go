@@ -0,0 +1,32 @@
+package tmpl
+
+import (
+	htmltemplate "html/template"
+	"strconv"
+)
+
+// errorCodeNames maps [htmltemplate.ErrorCode] values to their constant names.
+var errorCodeNames = [...]string{
+	htmltemplate.OK:                   "OK",
+	htmltemplate.ErrAmbigContext:      "ErrAmbigContext",
+	htmltemplate.ErrBadHTML:           "ErrBadHTML",
+	htmltemplate.ErrBranchEnd:         "ErrBranchEnd",
+	htmltemplate.ErrEndContext:        "ErrEndContext",
+	htmltemplate.ErrNoSuchTemplate:    "ErrNoSuchTemplate",
+	htmltemplate.ErrOutputContext:     "ErrOutputContext",
+	htmltemplate.ErrPartialCharset:    "ErrPartialCharset",
+	htmltemplate.ErrPartialEscape:     "ErrPartialEscape",
+	htmltemplate.ErrRangeLoopReentry:  "ErrRangeLoopReentry",
+	htmltemplate.ErrSlashAmbig:        "ErrSlashAmbig",
+	htmltemplate.ErrPredefinedEscaper: "ErrPredefinedEscaper",
+	htmltemplate.ErrJSTemplate:        "ErrJSTemplate", //nolint:staticcheck // Kept so the table covers every code.
+}
+
+// ErrorCodeName returns the name of the [htmltemplate.ErrorCode] constant for code,
+// such as "ErrBadHTML".
+func ErrorCodeName(code htmltemplate.ErrorCode) string {
+	if code < 0 || int(code) >= len(errorCodeNames) {
+		return "ErrorCode(" + strconv.Itoa(int(code)) + ")"
+	}
+	return errorCodeNames[code]
+}
@@ -2,6 +2,7 @@
 package tmpl
 
 import (
+	"maps"
 	"math"
 	"math/rand/v2"
 	"path/filepath"
@@ -115,6 +116,42 @@ func TemplateFuncs() template.FuncMap {
 	return defaultFuncsOnce.v
 }
 
+// FuncsOption configures the template functions returned by [NewFuncs].
+type FuncsOption func(*funcsOptions)
+
+type funcsOptions struct {
+	now  func() time.Time
+	rand *rand.Rand
+}
+
+// WithClock binds the now and date functions to the clock now.
+func WithClock(now func() time.Time) FuncsOption {
+	return func(o *funcsOptions) { o.now = now }
+}
+
+// WithRand binds the randInt function to the random source r.
+func WithRand(r *rand.Rand) FuncsOption {
+	return func(o *funcsOptions) { o.rand = r }
+}
+
+// NewFuncs returns a copy of the default template functions, with the time and random
+// functions bound as configured by opts. Unlike [TemplateFuncs], it builds a new map
+// on every call, so it should be called once per execution that needs its own clock
+// or random source.
+func NewFuncs(opts ...FuncsOption) template.FuncMap {
+	o := funcsOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	funcs := maps.Clone(TemplateFuncs())
+	funcs["now"] = o.now
+	funcs["date"] = func() string { return o.now().Format(time.DateOnly) }
+	if o.rand != nil {
+		funcs["randInt"] = o.rand.IntN
+	}
+	return funcs
+}
+
 // +-------------------------------+.
 
 // ucFirst converts the first character of s to uppercase.
@@ -160,7 +197,7 @@ func stripNewLines(s string) string {
 // :tsgen
 // :tsgen_category Time
 func date() string {
-	return time.Now().Format("2006-01-02")
+	return time.Now().Format(time.DateOnly)
 }
 
 // +-------------------------------+.
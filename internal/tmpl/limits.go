@@ -0,0 +1,341 @@
+package tmpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/util"
+)
+
+// Default limits.
+const (
+	DefaultTimeout        = 5 * time.Second
+	DefaultMaxOutputBytes = 8 << 20
+	DefaultMaxSteps       = 1_000_000
+	DefaultMaxDepth       = 256
+)
+
+// Limits bounds the resources used by executing a template. A zero value selects the
+// default for that limit, and a negative value disables it.
+type Limits struct {
+	Timeout        time.Duration // Wall-clock deadline for execution.
+	MaxOutputBytes int           // Maximum number of bytes written.
+	MaxSteps       int           // Maximum number of range iterations and template calls.
+	MaxDepth       int           // Maximum nesting depth of template calls.
+}
+
+func (l *Limits) init() {
+	l.Timeout = defaultLimit(l.Timeout, DefaultTimeout)
+	l.MaxOutputBytes = defaultLimit(l.MaxOutputBytes, DefaultMaxOutputBytes)
+	l.MaxSteps = defaultLimit(l.MaxSteps, DefaultMaxSteps)
+	l.MaxDepth = defaultLimit(l.MaxDepth, DefaultMaxDepth)
+}
+
+func defaultLimit[T int | time.Duration](v, def T) T {
+	switch {
+	case v == 0:
+		return def
+	case v < 0:
+		return 0
+	default:
+		return v
+	}
+}
+
+// Unmarshalls the javascript object into a Limits struct. The timeout is given in
+// milliseconds.
+func (l *Limits) UnmarshalJS(data util.JSValuer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	if data.Get("timeout").Truthy() {
+		l.Timeout = time.Duration(data.Get("timeout").Int()) * time.Millisecond
+	}
+	if data.Get("maxOutputBytes").Truthy() {
+		l.MaxOutputBytes = data.Get("maxOutputBytes").Int()
+	}
+	if data.Get("maxSteps").Truthy() {
+		l.MaxSteps = data.Get("maxSteps").Int()
+	}
+	if data.Get("maxDepth").Truthy() {
+		l.MaxDepth = data.Get("maxDepth").Int()
+	}
+	return nil
+}
+
+// Limit identifies one of the [Limits].
+type Limit string
+
+// Supported limits.
+const (
+	LimitTimeout Limit = "timeout"
+	LimitOutput  Limit = "output"
+	LimitSteps   Limit = "steps"
+	LimitDepth   Limit = "depth"
+)
+
+// LimitError is returned when executing a template exceeds one of its [Limits].
+type LimitError struct {
+	Limit Limit
+	Max   int64 // The exceeded maximum; nanoseconds for [LimitTimeout].
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case LimitTimeout:
+		return "limit exceeded: execution took longer than " + time.Duration(e.Max).String()
+	case LimitOutput:
+		return fmt.Sprintf("limit exceeded: output is larger than %d bytes", e.Max)
+	case LimitSteps:
+		return fmt.Sprintf("limit exceeded: execution took more than %d steps", e.Max)
+	case LimitDepth:
+		return fmt.Sprintf("limit exceeded: template calls are nested deeper than %d", e.Max)
+	}
+	return "limit exceeded: " + string(e.Limit)
+}
+
+// Code returns a machine-readable name for the exceeded limit, in the style of the
+// html/template error codes.
+func (e *LimitError) Code() string {
+	switch e.Limit {
+	case LimitTimeout:
+		return "ErrTimeout"
+	case LimitOutput:
+		return "ErrOutputLimit"
+	case LimitSteps:
+		return "ErrStepLimit"
+	case LimitDepth:
+		return "ErrDepthLimit"
+	}
+	return "ErrLimit"
+}
+
+// Names of the functions and variable used by the actions that [instrument] inserts.
+// The variable name is not a valid identifier, so it cannot hide a variable of the
+// template.
+const (
+	stepFunc  = "_limitStep"
+	enterFunc = "_limitEnter"
+	leaveFunc = "_limitLeave"
+	limitVar  = "$-limit"
+)
+
+// limitExecError is the cause of a [template.ExecError] returned by one of the
+// functions of a [limiter], reported at the location of the instrumented action
+// without naming the function.
+type limitExecError struct {
+	location string
+	err      error
+}
+
+func (e *limitExecError) Error() string { return e.location + ": " + e.err.Error() }
+
+func (e *limitExecError) Unwrap() error { return e.err }
+
+// unwrapLimitError returns err with the error returned by a function of a [limiter]
+// in place of the call that text/template reports around it. Other errors are
+// returned unchanged.
+func unwrapLimitError(err error) error {
+	var execErr template.ExecError
+	if !errors.As(err, &execErr) {
+		return err
+	}
+	msg := execErr.Err.Error()
+	i := strings.Index(msg, ": executing ")
+	cause := errors.Unwrap(execErr.Err)
+	if i < 0 || cause == nil || !strings.Contains(msg[i:], ": error calling _limit") {
+		return err
+	}
+	return template.ExecError{Name: execErr.Name, Err: &limitExecError{location: msg[:i], err: cause}}
+}
+
+// limiter enforces [Limits] during a single execution.
+type limiter struct {
+	ctx      context.Context
+	limits   Limits
+	deadline time.Time
+	steps    int
+	depth    int
+	yielded  time.Time // Time of the last yield; see [limiter.yield].
+}
+
+func newLimiter(ctx context.Context, limits Limits) *limiter {
+	limits.init()
+	l := &limiter{ctx: ctx, limits: limits, yielded: time.Now()}
+	if limits.Timeout > 0 {
+		l.deadline = time.Now().Add(limits.Timeout)
+	}
+	return l
+}
+
+// funcs returns the functions called by the instrumented actions.
+func (l *limiter) funcs() template.FuncMap {
+	return template.FuncMap{
+		stepFunc:  l.step,
+		enterFunc: l.enter,
+		leaveFunc: l.leave,
+	}
+}
+
+func (l *limiter) step() (string, error) {
+	l.steps++
+	if l.limits.MaxSteps > 0 && l.steps > l.limits.MaxSteps {
+		return "", &LimitError{Limit: LimitSteps, Max: int64(l.limits.MaxSteps)}
+	}
+	l.yield()
+	return "", l.checkDeadline()
+}
+
+func (l *limiter) enter() (string, error) {
+	l.depth++
+	if l.limits.MaxDepth > 0 && l.depth > l.limits.MaxDepth {
+		return "", &LimitError{Limit: LimitDepth, Max: int64(l.limits.MaxDepth)}
+	}
+	return l.step()
+}
+
+func (l *limiter) leave() (string, error) {
+	l.depth--
+	return "", nil
+}
+
+// yield parks the executing goroutine once every yieldInterval, if execution can be
+// canceled, so that the canceling code gets to run. It sleeps long enough for the
+// scheduler to go idle, rather than to resume the goroutine at once.
+func (l *limiter) yield() {
+	if yieldInterval == 0 || l.ctx.Done() == nil {
+		return
+	}
+	if now := time.Now(); now.Sub(l.yielded) >= yieldInterval {
+		time.Sleep(time.Millisecond)
+		l.yielded = time.Now()
+	}
+}
+
+func (l *limiter) checkDeadline() error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	if !l.deadline.IsZero() && time.Now().After(l.deadline) {
+		return &LimitError{Limit: LimitTimeout, Max: int64(l.limits.Timeout)}
+	}
+	return nil
+}
+
+// writer returns w wrapped so that writes fail once the output or time limit is exceeded.
+func (l *limiter) writer(w io.Writer) io.Writer {
+	return &limitWriter{w: w, l: l}
+}
+
+type limitWriter struct {
+	w io.Writer
+	l *limiter
+	n int
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	if err := lw.l.checkDeadline(); err != nil {
+		return 0, err
+	}
+	if limit := lw.l.limits.MaxOutputBytes; limit > 0 && lw.n+len(p) > limit {
+		n, _ := lw.w.Write(p[:limit-lw.n])
+		lw.n += n
+		return n, &LimitError{Limit: LimitOutput, Max: int64(limit)}
+	}
+	n, err := lw.w.Write(p)
+	lw.n += n
+	return n, err
+}
+
+// instrument inserts the actions that let a [limiter] interrupt execution of tree:
+// a step at the start of the template and of each range body, and enter and leave
+// actions around each {{template}} call. The actions declare a variable so that they
+// produce no output, even under html/template escaping. Instrumenting a tree twice
+// has no effect.
+func instrument(tree *parse.Tree) {
+	if tree == nil || tree.Root == nil || isLimitAction(tree.Root, stepFunc) {
+		return
+	}
+	instrumentList(tree, tree.Root)
+	tree.Root.Nodes = append([]parse.Node{limitAction(tree, stepFunc, tree.Root.Pos, 1)}, tree.Root.Nodes...)
+}
+
+func instrumentList(tree *parse.Tree, list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	nodes := make([]parse.Node, 0, len(list.Nodes))
+	for _, n := range list.Nodes {
+		switch n := n.(type) {
+		case *parse.IfNode:
+			instrumentList(tree, n.List)
+			instrumentList(tree, n.ElseList)
+		case *parse.WithNode:
+			instrumentList(tree, n.List)
+			instrumentList(tree, n.ElseList)
+		case *parse.RangeNode:
+			instrumentList(tree, n.List)
+			instrumentList(tree, n.ElseList)
+			n.List.Nodes = append([]parse.Node{limitAction(tree, stepFunc, n.Pos, n.Line)}, n.List.Nodes...)
+		case *parse.TemplateNode:
+			nodes = append(nodes,
+				limitAction(tree, enterFunc, n.Pos, n.Line),
+				n,
+				limitAction(tree, leaveFunc, n.Pos, n.Line),
+			)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	list.Nodes = nodes
+}
+
+// actionProto returns a parsed action. Its unexported tree supplies the delimiters
+// that [parse.ActionNode.String] requires, which a literal node lacks.
+var actionProto = sync.OnceValue(func() *parse.ActionNode {
+	tree, err := parse.New("").Parse("{{.}}", "", "", map[string]*parse.Tree{})
+	if err != nil {
+		panic(err)
+	}
+	return tree.Root.Nodes[0].(*parse.ActionNode)
+})
+
+// limitAction returns the action {{$-limit := fn}}, positioned at pos in tree.
+func limitAction(tree *parse.Tree, fn string, pos parse.Pos, line int) *parse.ActionNode {
+	action := *actionProto()
+	action.Pos, action.Line = pos, line
+	action.Pipe = &parse.PipeNode{
+		NodeType: parse.NodePipe,
+		Pos:      pos,
+		Line:     line,
+		Decl:     []*parse.VariableNode{{NodeType: parse.NodeVariable, Pos: pos, Ident: []string{limitVar}}},
+		Cmds: []*parse.CommandNode{{
+			NodeType: parse.NodeCommand,
+			Pos:      pos,
+			Args:     []parse.Node{parse.NewIdentifier(fn).SetTree(tree).SetPos(pos)},
+		}},
+	}
+	return &action
+}
+
+// isLimitAction reports whether list starts with an action calling fn.
+func isLimitAction(list *parse.ListNode, fn string) bool {
+	if len(list.Nodes) == 0 {
+		return false
+	}
+	action, ok := list.Nodes[0].(*parse.ActionNode)
+	if !ok || len(action.Pipe.Decl) != 1 || action.Pipe.Decl[0].Ident[0] != limitVar {
+		return false
+	}
+	ident, ok := action.Pipe.Cmds[0].Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == fn
+}
@@ -0,0 +1,143 @@
+package tmpl
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_Execute_limits(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   Engine
+		files    Files
+		limits   Limits
+		want     string
+		wantErr  Limit
+		contains string
+	}{
+		{
+			name:   "WithinLimits",
+			files:  Files{"a": []byte(`{{define "b"}}[{{.}}]{{end}}{{range 3}}{{template "b" .}}{{end}}`)},
+			limits: Limits{MaxSteps: 10, MaxDepth: 1, MaxOutputBytes: 9},
+			want:   "[0][1][2]",
+		},
+		{
+			name:     "Steps",
+			files:    Files{"a": []byte(`{{range 100000000}}{{end}}`)},
+			limits:   Limits{MaxSteps: 100},
+			wantErr:  LimitSteps,
+			contains: "template: a:1:8: limit exceeded: execution took more than 100 steps",
+		},
+		{
+			name:    "Depth",
+			files:   Files{"a": []byte(`{{define "r"}}{{template "r" .}}{{end}}{{template "r" .}}`)},
+			limits:  Limits{MaxDepth: 10},
+			wantErr: LimitDepth,
+		},
+		{
+			name:    "Output",
+			files:   Files{"a": []byte(`{{range 10}}abc{{end}}`)},
+			limits:  Limits{MaxOutputBytes: 10},
+			want:    "abcabcabca",
+			wantErr: LimitOutput,
+		},
+		{
+			name:    "Timeout",
+			files:   Files{"a": []byte(`{{range 100000000}}{{end}}`)},
+			limits:  Limits{Timeout: time.Millisecond, MaxSteps: -1},
+			wantErr: LimitTimeout,
+		},
+		{
+			name:    "HTML",
+			engine:  EngineHTML,
+			files:   Files{"a": []byte(`<script>var x = {{range 2}}{{template "b"}}{{end}};</script>{{define "b"}}1{{end}}`)},
+			limits:  Limits{MaxSteps: 10},
+			want:    "<script>var x = 11;</script>",
+			wantErr: "",
+		},
+		{
+			name:   "UserVariable",
+			files:  Files{"a": []byte(`{{$_limit := 3}}{{range $i := 2}}{{$_limit}}{{end}}`)},
+			limits: Limits{MaxSteps: 10},
+			want:   "33",
+		},
+		{
+			name:    "Disabled",
+			files:   Files{"a": []byte(`{{range 3}}x{{end}}`)},
+			limits:  Limits{MaxSteps: -1, MaxOutputBytes: -1, MaxDepth: -1, Timeout: -1},
+			want:    "xxx",
+			wantErr: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseFiles(tt.files, Options{Engine: tt.engine}, nil)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = set.Execute(&buf, nil, tt.limits)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				var limitErr *LimitError
+				require.ErrorAs(t, err, &limitErr)
+				assert.Equal(t, tt.wantErr, limitErr.Limit)
+				assert.Contains(t, err.Error(), tt.contains)
+				assert.NotContains(t, err.Error(), "_limit", "the instrumented actions are not exposed")
+			}
+			if tt.want != "" {
+				assert.Equal(t, tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestTemplate_ExecuteContext(t *testing.T) {
+	for _, engine := range []Engine{EngineText, EngineHTML} {
+		t.Run(string(engine), func(t *testing.T) {
+			set, err := ParseFiles(Files{"a": []byte(`{{range 2}}{{cancel}}{{end}}`)}, Options{Engine: engine}, template.FuncMap{"cancel": func() string { return "" }})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			set.Funcs(template.FuncMap{"cancel": func() string { cancel(); return "x" }})
+			var buf bytes.Buffer
+			err = set.ExecuteContext(ctx, &buf, nil, Limits{})
+			require.ErrorIs(t, err, context.Canceled)
+			assert.Empty(t, buf.String(), "nothing is written once ctx is done")
+		})
+	}
+}
+
+func TestInstrument_idempotent(t *testing.T) {
+	set, err := ParseFiles(Files{"a": []byte(`{{range 2}}{{template "b"}}{{end}}{{define "b"}}b{{end}}`)}, Options{}, nil)
+	require.NoError(t, err)
+	before := set.text.Tree.Root.String()
+	assert.Equal(t, `{{$-limit := _limitStep}}{{range 2}}{{$-limit := _limitStep}}{{$-limit := _limitEnter}}{{template "b"}}{{$-limit := _limitLeave}}{{end}}`, before)
+	instrument(set.text.Tree)
+	assert.Equal(t, before, set.text.Tree.Root.String())
+}
+
+func TestLimitError(t *testing.T) {
+	tests := []struct {
+		err  LimitError
+		msg  string
+		code string
+	}{
+		{LimitError{LimitTimeout, int64(time.Second)}, "limit exceeded: execution took longer than 1s", "ErrTimeout"},
+		{LimitError{LimitOutput, 10}, "limit exceeded: output is larger than 10 bytes", "ErrOutputLimit"},
+		{LimitError{LimitSteps, 10}, "limit exceeded: execution took more than 10 steps", "ErrStepLimit"},
+		{LimitError{LimitDepth, 10}, "limit exceeded: template calls are nested deeper than 10", "ErrDepthLimit"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.err.Limit), func(t *testing.T) {
+			assert.EqualError(t, &tt.err, tt.msg)
+			assert.Equal(t, tt.code, tt.err.Code())
+		})
+	}
+}
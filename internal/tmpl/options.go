@@ -0,0 +1,78 @@
+package tmpl
+
+import (
+	"cmp"
+	"errors"
+	"text/template"
+
+	"github.com/bartventer/go-template-playground/internal/util"
+)
+
+// Options holds configuration settings for parsing and executing templates.
+type Options struct {
+	Entry      string     // Name of the template to execute; defaults to the root template.
+	Engine     Engine     // Template engine; defaults to [EngineText].
+	Name       string     // Name of a template parsed from a single source; defaults to [DefaultName].
+	LeftDelim  string     // Left action delimiter; defaults to "{{".
+	RightDelim string     // Right action delimiter; defaults to "}}".
+	MissingKey MissingKey // Behavior when a map has no entry for a key; defaults to [MissingKeyDefault].
+	Limits     Limits     // Execution limits; zero values select the defaults.
+	Trace      bool       // Instrument templates to record a [Trace] of their execution.
+
+	// Deterministic fixes the results of now, date and randInt, if set.
+	Deterministic *Determinism
+}
+
+// RootName returns the name of a template parsed from a single source.
+func (o *Options) RootName() string {
+	return cmp.Or(o.Name, DefaultName)
+}
+
+// Funcs returns the template functions to execute with. Unless the options are
+// deterministic, these are the shared [TemplateFuncs].
+func (o *Options) Funcs() template.FuncMap {
+	if o.Deterministic == nil {
+		return TemplateFuncs()
+	}
+	return o.Deterministic.Funcs()
+}
+
+// Unmarshalls the javascript object into an Options struct.
+func (o *Options) UnmarshalJS(data util.JSValuer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	if data.Get("entry").Truthy() {
+		o.Entry = data.Get("entry").String()
+	}
+	if data.Get("engine").Truthy() {
+		o.Engine = Engine(data.Get("engine").String())
+	}
+	if data.Get("name").Truthy() {
+		o.Name = data.Get("name").String()
+	}
+	if delims := data.Get("delims"); delims.Truthy() {
+		left, right := delims.Get("0"), delims.Get("1")
+		if delims.TypeOf() != "object" || delims.Length() != 2 ||
+			left.TypeOf() != "string" || right.TypeOf() != "string" ||
+			left.String() == "" || right.String() == "" {
+			return errors.New("delims must be an array of two non-empty strings")
+		}
+		o.LeftDelim, o.RightDelim = left.String(), right.String()
+	}
+	if data.Get("missingKey").Truthy() {
+		o.MissingKey = MissingKey(data.Get("missingKey").String())
+	}
+	if data.Get("deterministic").Truthy() {
+		o.Deterministic = new(Determinism)
+		if err := o.Deterministic.UnmarshalJS(data.Get("deterministic")); err != nil {
+			return err
+		}
+	}
+	if data.Get("limits").Truthy() {
+		return o.Limits.UnmarshalJS(data.Get("limits"))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+//go:build js && wasm
+// +build js,wasm
+
+package tmpl
+
+import (
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/jsutil"
+	"github.com/bartventer/go-template-playground/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions_UnmarshalJS(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      util.JSValuer
+		want      Options
+		assertion assert.ErrorAssertionFunc
+	}{
+		{
+			name: "Valid",
+			data: jsutil.JSValueWrapper{
+				Value: js.ValueOf(map[string]interface{}{
+					"entry":      "index.tmpl",
+					"engine":     "html",
+					"name":       "page.html",
+					"delims":     []interface{}{"[[", "]]"},
+					"missingKey": "error",
+					"deterministic": map[string]interface{}{
+						"now":      "2024-01-02T03:04:05Z",
+						"timeZone": "UTC",
+						"seed":     7,
+					},
+					"limits": map[string]interface{}{
+						"timeout":        1500,
+						"maxOutputBytes": 1024,
+						"maxSteps":       100,
+						"maxDepth":       -1,
+					},
+				}),
+			},
+			want: Options{
+				Entry:      "index.tmpl",
+				Engine:     EngineHTML,
+				Name:       "page.html",
+				LeftDelim:  "[[",
+				RightDelim: "]]",
+				MissingKey: MissingKeyError,
+				Deterministic: &Determinism{
+					Now:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+					Location: time.UTC,
+					Seed:     7,
+				},
+				Limits: Limits{
+					Timeout:        1500 * time.Millisecond,
+					MaxOutputBytes: 1024,
+					MaxSteps:       100,
+					MaxDepth:       -1,
+				},
+			},
+			assertion: assert.NoError,
+		},
+		{
+			name:      "Empty",
+			data:      jsutil.JSValueWrapper{Value: js.ValueOf(map[string]interface{}{})},
+			want:      Options{},
+			assertion: assert.NoError,
+		},
+		{
+			name: "InvalidTimeZone",
+			data: jsutil.JSValueWrapper{
+				Value: js.ValueOf(map[string]interface{}{"deterministic": map[string]interface{}{"timeZone": "Mars/Olympus"}}),
+			},
+			assertion: assert.Error,
+		},
+		{
+			name: "InvalidNow",
+			data: jsutil.JSValueWrapper{
+				Value: js.ValueOf(map[string]interface{}{"deterministic": map[string]interface{}{"now": "yesterday"}}),
+			},
+			assertion: assert.Error,
+		},
+		{
+			name: "InvalidLimits",
+			data: jsutil.JSValueWrapper{
+				Value: js.ValueOf(map[string]interface{}{"limits": map[string]interface{}{"maxSteps": "many"}}),
+			},
+			assertion: assert.Error,
+		},
+		{
+			name:      "OneDelim",
+			data:      jsutil.JSValueWrapper{Value: js.ValueOf(map[string]interface{}{"delims": []interface{}{"[["}})},
+			assertion: assert.Error,
+		},
+		{
+			name:      "EmptyDelim",
+			data:      jsutil.JSValueWrapper{Value: js.ValueOf(map[string]interface{}{"delims": []interface{}{"[[", ""}})},
+			assertion: assert.Error,
+		},
+		{
+			name:      "NumberDelims",
+			data:      jsutil.JSValueWrapper{Value: js.ValueOf(map[string]interface{}{"delims": []interface{}{1, 2}})},
+			assertion: assert.Error,
+		},
+		{
+			name:      "StringDelims",
+			data:      jsutil.JSValueWrapper{Value: js.ValueOf(map[string]interface{}{"delims": "[]"})},
+			assertion: assert.Error,
+		},
+		{
+			name:      "Invalid",
+			data:      jsutil.JSValueWrapper{Value: js.ValueOf("not an object")},
+			assertion: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o Options
+			err := o.UnmarshalJS(tt.data)
+			tt.assertion(t, err)
+			if err == nil {
+				assert.Equal(t, tt.want, o)
+			}
+		})
+	}
+}
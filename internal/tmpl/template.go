@@ -0,0 +1,252 @@
+package tmpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"slices"
+	"text/template"
+)
+
+// DefaultName is the name of a template parsed from a single source.
+const DefaultName = "template"
+
+// ErrNoEntry is returned when the entry template of a multi-file set is ambiguous.
+var ErrNoEntry = errors.New("no entry template specified")
+
+// Engine identifies the package used to parse and execute templates.
+type Engine string
+
+// Supported engines.
+const (
+	EngineText Engine = "text" // text/template
+	EngineHTML Engine = "html" // html/template, with contextual auto-escaping
+)
+
+// Engines returns the supported engines.
+func Engines() []Engine {
+	return []Engine{EngineText, EngineHTML}
+}
+
+// MissingKey controls the behavior during execution if a map is indexed with a key
+// that is not present in the map.
+type MissingKey string
+
+// Supported missing key behaviors, as documented for [template.Template.Option].
+const (
+	MissingKeyDefault MissingKey = "default" // Print "<no value>" and continue.
+	MissingKeyZero    MissingKey = "zero"    // Return the zero value for the map type's element.
+	MissingKeyError   MissingKey = "error"   // Stop execution with an error.
+)
+
+// MissingKeys returns the supported missing key behaviors.
+func MissingKeys() []MissingKey {
+	return []MissingKey{MissingKeyDefault, MissingKeyZero, MissingKeyError}
+}
+
+// option returns the argument to [template.Template.Option] that selects m.
+// An empty m selects [MissingKeyDefault].
+func (m MissingKey) option() (string, error) {
+	switch m {
+	case "":
+		return "missingkey=" + string(MissingKeyDefault), nil
+	case MissingKeyDefault, MissingKeyZero, MissingKeyError:
+		return "missingkey=" + string(m), nil
+	default:
+		return "", fmt.Errorf("unsupported missingkey: %s", m)
+	}
+}
+
+// Files maps template file names to their source.
+type Files map[string][]byte
+
+// Names returns the file names in sorted order.
+func (f Files) Names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Template is a template set parsed by one of the supported engines.
+type Template struct {
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+// Name returns the name of the template.
+func (t *Template) Name() string {
+	if t.html != nil {
+		return t.html.Name()
+	}
+	return t.text.Name()
+}
+
+// Lookup returns the template with the given name that is associated with t,
+// or nil if there is no such template.
+func (t *Template) Lookup(name string) *Template {
+	if t.html != nil {
+		if h := t.html.Lookup(name); h != nil {
+			return &Template{html: h}
+		}
+		return nil
+	}
+	if x := t.text.Lookup(name); x != nil {
+		return &Template{text: x}
+	}
+	return nil
+}
+
+// Funcs adds the elements of funcs to the function map of the template set, replacing
+// functions of the same name. It returns t.
+func (t *Template) Funcs(funcs template.FuncMap) *Template {
+	if t.html != nil {
+		t.html.Funcs(htmltemplate.FuncMap(funcs))
+	} else {
+		t.text.Funcs(funcs)
+	}
+	return t
+}
+
+// Execute applies the template to the specified data object, writing the output to w.
+// Execution is aborted with a [*LimitError] once it exceeds limits.
+func (t *Template) Execute(w io.Writer, data any, limits Limits) error {
+	return t.ExecuteContext(context.Background(), w, data, limits)
+}
+
+// ExecuteContext is like [Template.Execute], but also aborts execution with the error
+// of ctx once ctx is done. Like the time limit, this is checked at each step and write.
+func (t *Template) ExecuteContext(ctx context.Context, w io.Writer, data any, limits Limits) error {
+	return t.ExecuteTrace(ctx, w, data, limits, nil)
+}
+
+// ExecuteTrace is like [Template.ExecuteContext], but also records the events of the
+// execution in trace, if the template was parsed with [Options.Trace] and trace is not
+// nil.
+func (t *Template) ExecuteTrace(ctx context.Context, w io.Writer, data any, limits Limits, trace *Trace) error {
+	l := newLimiter(ctx, limits)
+	funcs := l.funcs()
+	if trace != nil {
+		maps.Copy(funcs, traceFuncs(trace))
+	}
+	if t.html != nil {
+		return unwrapLimitError(t.html.Funcs(htmltemplate.FuncMap(funcs)).Execute(l.writer(w), data))
+	}
+	return unwrapLimitError(t.text.Funcs(funcs).Execute(l.writer(w), data))
+}
+
+// Clone returns a duplicate of the template set, including all associated templates.
+// Executing the clone does not affect t, so a set can be parsed once and executed
+// many times, even with html/template, which escapes a set when it is first executed.
+func (t *Template) Clone() (*Template, error) {
+	if t.html != nil {
+		h, err := t.html.Clone()
+		if err != nil {
+			return nil, err
+		}
+		return &Template{html: h}, nil
+	}
+	x, err := t.text.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return &Template{text: x}, nil
+}
+
+// ParseFiles parses files into a single template set, in the manner of [template.ParseFS].
+// Each file is associated with a template named after the file, so that
+// {{template "header.tmpl" .}} resolves across files. Files are parsed in sorted
+// order, and the first file becomes the root of the set.
+//
+// The engine, delimiters, missing key behavior and tracing are taken from options; its
+// other fields are ignored.
+func ParseFiles(files Files, options Options, funcs template.FuncMap) (*Template, error) {
+	missingKey, err := options.MissingKey.option()
+	if err != nil {
+		return nil, err
+	}
+	switch options.Engine {
+	case "", EngineText:
+		set, err := parseFiles(files, func(name string) *template.Template {
+			return template.New(name).Delims(options.LeftDelim, options.RightDelim).Option(missingKey).Funcs(funcs)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range set.Templates() {
+			if options.Trace {
+				instrumentTrace(x.Tree)
+			}
+			instrument(x.Tree)
+		}
+		if options.Trace {
+			set.Funcs(traceFuncs(nil))
+		}
+		return &Template{text: set}, nil
+	case EngineHTML:
+		set, err := parseFiles(files, func(name string) *htmltemplate.Template {
+			return htmltemplate.New(name).Delims(options.LeftDelim, options.RightDelim).Option(missingKey).Funcs(htmltemplate.FuncMap(funcs))
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range set.Templates() {
+			if options.Trace {
+				instrumentTrace(x.Tree)
+			}
+			instrument(x.Tree)
+		}
+		if options.Trace {
+			set.Funcs(htmltemplate.FuncMap(traceFuncs(nil)))
+		}
+		return &Template{html: set}, nil
+	default:
+		return nil, fmt.Errorf("unsupported engine: %s", options.Engine)
+	}
+}
+
+// templateSet is implemented by [template.Template] and [htmltemplate.Template].
+type templateSet[T any] interface {
+	New(name string) T
+	Parse(text string) (T, error)
+}
+
+func parseFiles[T templateSet[T]](files Files, newSet func(name string) T) (T, error) {
+	names := files.Names()
+	if len(names) == 0 {
+		return newSet(DefaultName), nil
+	}
+	set := newSet(names[0])
+	for i, name := range names {
+		t := set
+		if i > 0 {
+			t = set.New(name)
+		}
+		if _, err := t.Parse(string(files[name])); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	return set, nil
+}
+
+// Entry returns the template in set named by entry. If entry is empty, the set must
+// consist of a single file, whose template is returned.
+func Entry(set *Template, files Files, entry string) (*Template, error) {
+	if entry == "" {
+		if len(files) != 1 {
+			return nil, ErrNoEntry
+		}
+		return set, nil
+	}
+	t := set.Lookup(entry)
+	if t == nil {
+		return nil, fmt.Errorf("template %q is not defined", entry)
+	}
+	return t, nil
+}
@@ -0,0 +1,147 @@
+package tmpl
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles_Names(t *testing.T) {
+	files := Files{"b": nil, "c": nil, "a": nil}
+	assert.Equal(t, []string{"a", "b", "c"}, files.Names())
+}
+
+func TestParseFiles(t *testing.T) {
+	files := Files{
+		"header.tmpl": []byte(`Hello, {{.}}!`),
+		"index.tmpl":  []byte(`{{template "header.tmpl" .}} {{upper "bye"}}`),
+	}
+	set, err := ParseFiles(files, Options{}, TemplateFuncs())
+	require.NoError(t, err)
+	assert.Equal(t, "header.tmpl", set.Name())
+
+	entry, err := Entry(set, files, "index.tmpl")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, entry.Execute(&buf, "World", Limits{}))
+	assert.Equal(t, "Hello, World! BYE", buf.String())
+}
+
+func TestParseFiles_Error(t *testing.T) {
+	_, err := ParseFiles(Files{"bad.tmpl": []byte(`{{.Name`)}, Options{}, TemplateFuncs())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad.tmpl:1")
+}
+
+func TestParseFiles_HTML(t *testing.T) {
+	files := Files{"page.html": []byte(`<p title="{{.}}">{{.}}</p>`)}
+	set, err := ParseFiles(files, Options{Engine: EngineHTML}, TemplateFuncs())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, set.Execute(&buf, `"<b>"`, Limits{}))
+	assert.Equal(t, `<p title="&#34;&lt;b&gt;&#34;">&#34;&lt;b&gt;&#34;</p>`, buf.String())
+}
+
+func TestParseFiles_Options(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		src     string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Delims",
+			options: Options{LeftDelim: "[[", RightDelim: "]]"},
+			src:     `{{ .A }} [[.A]]`,
+			want:    `{{ .A }} 1`,
+		},
+		{
+			name:    "DelimsHTML",
+			options: Options{Engine: EngineHTML, LeftDelim: "<%", RightDelim: "%>"},
+			src:     `<p><%.A%></p>`,
+			want:    `<p>1</p>`,
+		},
+		{
+			name: "MissingKeyDefault",
+			src:  `{{.B}}`,
+			want: `<no value>`,
+		},
+		{
+			name:    "MissingKeyZero",
+			options: Options{MissingKey: MissingKeyZero},
+			src:     `{{.B}}`,
+			want:    `0`,
+		},
+		{
+			name:    "MissingKeyError",
+			options: Options{MissingKey: MissingKeyError},
+			src:     `{{.B}}`,
+			wantErr: `map has no entry for key "B"`,
+		},
+		{
+			name:    "UnsupportedMissingKey",
+			options: Options{MissingKey: "ignore"},
+			wantErr: "unsupported missingkey: ignore",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseFiles(Files{"t": []byte(tt.src)}, tt.options, nil)
+			var buf bytes.Buffer
+			if err == nil {
+				err = set.Execute(&buf, map[string]int{"A": 1}, Limits{})
+			}
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestParseFiles_UnsupportedEngine(t *testing.T) {
+	_, err := ParseFiles(Files{"a": nil}, Options{Engine: "jinja"}, nil)
+	require.EqualError(t, err, "unsupported engine: jinja")
+}
+
+func TestEntry(t *testing.T) {
+	single := Files{"a.tmpl": []byte(`a{{define "b"}}b{{end}}`)}
+	set, err := ParseFiles(single, Options{}, nil)
+	require.NoError(t, err)
+
+	t.Run("Default", func(t *testing.T) {
+		got, err := Entry(set, single, "")
+		require.NoError(t, err)
+		assert.Equal(t, "a.tmpl", got.Name())
+	})
+
+	t.Run("Define", func(t *testing.T) {
+		got, err := Entry(set, single, "b")
+		require.NoError(t, err)
+		assert.Equal(t, "b", got.Name())
+	})
+
+	t.Run("Undefined", func(t *testing.T) {
+		_, err := Entry(set, single, "c")
+		require.EqualError(t, err, `template "c" is not defined`)
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		_, err := Entry(set, Files{"a.tmpl": nil, "b.tmpl": nil}, "")
+		require.ErrorIs(t, err, ErrNoEntry)
+	})
+}
+
+func TestErrorCodeName(t *testing.T) {
+	assert.Equal(t, "ErrBadHTML", ErrorCodeName(htmltemplate.ErrBadHTML))
+	assert.Equal(t, "ErrPredefinedEscaper", ErrorCodeName(htmltemplate.ErrPredefinedEscaper))
+	assert.Equal(t, "ErrorCode(99)", ErrorCodeName(99))
+}
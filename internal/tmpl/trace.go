@@ -0,0 +1,328 @@
+package tmpl
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode/utf8"
+)
+
+// Bounds of a [Trace].
+const (
+	MaxTraceEvents   = 10_000 // Maximum number of events recorded.
+	MaxTraceValueLen = 200    // Maximum length in bytes of a formatted value.
+)
+
+// TraceKind identifies the node whose pipeline a [TraceEvent] records.
+type TraceKind string
+
+// Traced nodes.
+const (
+	TraceAction   TraceKind = "action"   // {{pipeline}}, including declarations.
+	TraceIf       TraceKind = "if"       // {{if pipeline}}.
+	TraceRange    TraceKind = "range"    // {{range pipeline}}.
+	TraceWith     TraceKind = "with"     // {{with pipeline}}.
+	TraceTemplate TraceKind = "template" // {{template "name" pipeline}}.
+)
+
+// TraceEvent records the evaluation of a pipeline.
+type TraceEvent struct {
+	Kind     TraceKind `json:"kind"`
+	Pipeline string    `json:"pipeline"` // The pipeline, as printed by its parse tree.
+	Template string    `json:"template"` // Name of the template source.
+	Line     int       `json:"line"`     // 1-based line.
+	Column   int       `json:"column"`   // 1-based column.
+	Offset   int       `json:"offset"`   // 0-based byte offset.
+	Dot      string    `json:"dot"`      // Value of dot, formatted with %v and truncated.
+	Value    string    `json:"value"`    // Value of the pipeline, formatted with %v and truncated.
+	Type     string    `json:"type"`     // Type of the value, formatted with %T.
+}
+
+// Trace holds the events recorded by executing a template parsed with [Options.Trace],
+// in the order in which the pipelines were evaluated.
+type Trace struct {
+	Events    []TraceEvent `json:"events"`
+	Truncated bool         `json:"truncated"` // Whether events were dropped after MaxTraceEvents.
+}
+
+// traceFunc is the name of the function called by the pipelines that [instrumentTrace]
+// rewrites.
+const traceFunc = "_trace"
+
+// traceFuncs returns the functions called by the instrumented pipelines, which record
+// events in trace, unless it is nil.
+func traceFuncs(trace *Trace) template.FuncMap {
+	return template.FuncMap{
+		traceFunc: func(location string, offset int, kind TraceKind, pipeline string, dot, v any) any {
+			if trace == nil {
+				return v
+			}
+			if len(trace.Events) == MaxTraceEvents {
+				trace.Truncated = true
+				return v
+			}
+			name, line, column := splitLocation(location)
+			trace.Events = append(trace.Events, TraceEvent{
+				Kind:     kind,
+				Pipeline: pipeline,
+				Template: name,
+				Line:     line,
+				Column:   column,
+				Offset:   offset,
+				Dot:      formatValue(dot),
+				Value:    formatValue(v),
+				Type:     fmt.Sprintf("%T", v),
+			})
+			return v
+		},
+	}
+}
+
+// truncate shortens s to at most MaxTraceValueLen bytes, on a rune boundary, marking
+// the cut with an ellipsis.
+func truncate(s string) string {
+	if len(s) <= MaxTraceValueLen {
+		return s
+	}
+	const ellipsis = "…"
+	n := MaxTraceValueLen - len(ellipsis)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + ellipsis
+}
+
+// formatValue formats v like %v, truncated as by [truncate]. Formatting stops once the
+// output is longer than MaxTraceValueLen, so that large values cost about as much as
+// small ones, save for sorting the keys of maps.
+func formatValue(v any) string {
+	var w traceWriter
+	if v == nil {
+		io.WriteString(&w, "<nil>")
+	} else {
+		w.print(reflect.ValueOf(v), 0)
+	}
+	return truncate(string(w.buf))
+}
+
+// traceWriter keeps the first MaxTraceValueLen+1 bytes written to it, which is enough
+// for [truncate] to mark the cut.
+type traceWriter struct {
+	buf []byte
+}
+
+func (w *traceWriter) Write(p []byte) (int, error) {
+	if n := MaxTraceValueLen + 1 - len(w.buf); n > 0 {
+		w.buf = append(w.buf, p[:min(n, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// full reports whether anything more written to w would be cut.
+func (w *traceWriter) full() bool { return len(w.buf) > MaxTraceValueLen }
+
+// print writes v to w as fmt prints it with %v at depth, walking arrays, slices, maps
+// and structs itself and leaving other values, and values with a method that fmt
+// calls, to fmt.
+func (w *traceWriter) print(v reflect.Value, depth int) {
+	if w.full() {
+		return
+	}
+	if v.CanInterface() {
+		switch v.Interface().(type) {
+		case fmt.Formatter, fmt.Stringer, error:
+			fmt.Fprint(w, v.Interface())
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		io.WriteString(w, "<invalid reflect.Value>")
+	case reflect.String:
+		io.WriteString(w, v.String())
+	case reflect.Interface:
+		if v.IsNil() {
+			io.WriteString(w, "<nil>")
+			return
+		}
+		w.print(v.Elem(), depth+1)
+	case reflect.Pointer:
+		switch e := v.Elem(); {
+		case v.IsNil():
+			io.WriteString(w, "<nil>")
+		case depth == 0 && (e.Kind() == reflect.Array || e.Kind() == reflect.Slice || e.Kind() == reflect.Struct || e.Kind() == reflect.Map):
+			io.WriteString(w, "&")
+			w.print(e, depth+1)
+		default:
+			fmt.Fprintf(w, "%#x", v.Pointer())
+		}
+	case reflect.Array, reflect.Slice:
+		io.WriteString(w, "[")
+		for i := 0; i < v.Len() && !w.full(); i++ {
+			if i > 0 {
+				io.WriteString(w, " ")
+			}
+			w.print(v.Index(i), depth+1)
+		}
+		io.WriteString(w, "]")
+	case reflect.Struct:
+		io.WriteString(w, "{")
+		for i := 0; i < v.NumField() && !w.full(); i++ {
+			if i > 0 {
+				io.WriteString(w, " ")
+			}
+			w.print(v.Field(i), depth+1)
+		}
+		io.WriteString(w, "}")
+	case reflect.Map:
+		keys := v.MapKeys()
+		if !sortKeys(keys) {
+			fmt.Fprint(w, v)
+			return
+		}
+		io.WriteString(w, "map[")
+		for i, k := range keys {
+			if w.full() {
+				break
+			}
+			if i > 0 {
+				io.WriteString(w, " ")
+			}
+			w.print(k, depth+1)
+			io.WriteString(w, ":")
+			w.print(v.MapIndex(k), depth+1)
+		}
+		io.WriteString(w, "]")
+	default:
+		fmt.Fprint(w, v)
+	}
+}
+
+// sortKeys sorts the map keys as fmt prints them, and reports whether it could: only
+// keys of basic kinds are sorted.
+func sortKeys(keys []reflect.Value) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	var compare func(a, b reflect.Value) int
+	switch keys[0].Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		compare = func(a, b reflect.Value) int { return cmp.Compare(a.Int(), b.Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		compare = func(a, b reflect.Value) int { return cmp.Compare(a.Uint(), b.Uint()) }
+	case reflect.Float32, reflect.Float64:
+		compare = func(a, b reflect.Value) int { return cmp.Compare(a.Float(), b.Float()) }
+	case reflect.String:
+		compare = func(a, b reflect.Value) int { return cmp.Compare(a.String(), b.String()) }
+	case reflect.Bool:
+		compare = func(a, b reflect.Value) int {
+			switch {
+			case a.Bool() == b.Bool():
+				return 0
+			case b.Bool():
+				return -1
+			}
+			return 1
+		}
+	default:
+		return false
+	}
+	slices.SortStableFunc(keys, compare)
+	return true
+}
+
+// splitLocation splits a location returned by [parse.Tree.ErrorContext], of the form
+// "name:line:column" with a 0-based column, into its parts, with a 1-based column.
+func splitLocation(location string) (name string, line, column int) {
+	rest, col, _ := cutLast(location, ":")
+	name, ln, _ := cutLast(rest, ":")
+	line, _ = strconv.Atoi(ln)
+	column, _ = strconv.Atoi(col)
+	return name, line, column + 1
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// instrumentTrace rewrites the pipeline P of each action, if, range, with and template
+// node of tree into _trace "location" offset "kind" "P" . (P), which records the value
+// of P and the value of dot, and evaluates to the value of P. Declarations stay on the
+// outer pipeline, so that they bind the same values. It must be called before
+// [instrument], so that the limit actions are not traced.
+func instrumentTrace(tree *parse.Tree) {
+	if tree == nil || tree.Root == nil {
+		return
+	}
+	traceList(tree, tree.Root)
+}
+
+func traceList(tree *parse.Tree, list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	for _, n := range list.Nodes {
+		switch n := n.(type) {
+		case *parse.ActionNode:
+			n.Pipe = tracePipe(tree, TraceAction, n.Pipe)
+		case *parse.IfNode:
+			n.Pipe = tracePipe(tree, TraceIf, n.Pipe)
+			traceList(tree, n.List)
+			traceList(tree, n.ElseList)
+		case *parse.RangeNode:
+			n.Pipe = tracePipe(tree, TraceRange, n.Pipe)
+			traceList(tree, n.List)
+			traceList(tree, n.ElseList)
+		case *parse.WithNode:
+			n.Pipe = tracePipe(tree, TraceWith, n.Pipe)
+			traceList(tree, n.List)
+			traceList(tree, n.ElseList)
+		case *parse.TemplateNode:
+			if n.Pipe != nil {
+				n.Pipe = tracePipe(tree, TraceTemplate, n.Pipe)
+			}
+		}
+	}
+}
+
+// tracePipe returns pipe rewritten to record its value, as the pipeline of a node of
+// kind.
+func tracePipe(tree *parse.Tree, kind TraceKind, pipe *parse.PipeNode) *parse.PipeNode {
+	inner := *pipe
+	inner.IsAssign, inner.Decl = false, nil
+	location, _ := tree.ErrorContext(pipe)
+	pos := pipe.Pos
+	return &parse.PipeNode{
+		NodeType: parse.NodePipe,
+		Pos:      pos,
+		Line:     pipe.Line,
+		IsAssign: pipe.IsAssign,
+		Decl:     pipe.Decl,
+		Cmds: []*parse.CommandNode{{
+			NodeType: parse.NodeCommand,
+			Pos:      pos,
+			Args: []parse.Node{
+				parse.NewIdentifier(traceFunc).SetTree(tree).SetPos(pos),
+				stringNode(pos, location),
+				&parse.NumberNode{NodeType: parse.NodeNumber, Pos: pos, IsInt: true, Int64: int64(pos), Text: strconv.Itoa(int(pos))},
+				stringNode(pos, string(kind)),
+				stringNode(pos, pipe.String()),
+				&parse.DotNode{NodeType: parse.NodeDot, Pos: pos},
+				&inner,
+			},
+		}},
+	}
+}
+
+func stringNode(pos parse.Pos, s string) *parse.StringNode {
+	return &parse.StringNode{NodeType: parse.NodeString, Pos: pos, Quoted: strconv.Quote(s), Text: s}
+}
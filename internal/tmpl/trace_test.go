@@ -0,0 +1,165 @@
+package tmpl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_ExecuteTrace(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine Engine
+		files  Files
+		data   any
+		want   string
+		events []TraceEvent
+	}{
+		{
+			name:  "Pipeline",
+			files: Files{"a": []byte("n={{ .Items | len | printf \"%03d\" }}")},
+			data:  map[string]any{"Items": []int{1, 2}},
+			want:  "n=002",
+			events: []TraceEvent{
+				{Kind: TraceAction, Pipeline: `.Items | len | printf "%03d"`, Template: "a", Line: 1, Column: 6, Offset: 5, Dot: "map[Items:[1 2]]", Value: "002", Type: "string"},
+			},
+		},
+		{
+			name:  "ControlStructures",
+			files: Files{"a": []byte("{{define \"b\"}}<{{.}}>{{end}}{{range $i, $x := .}}{{if $x}}{{template \"b\" $i}}{{end}}{{end}}{{$y := 1}}")},
+			data:  []bool{true, false},
+			want:  "<0>",
+			events: []TraceEvent{
+				{Kind: TraceRange, Pipeline: "$i, $x := .", Template: "a", Line: 1, Column: 37, Offset: 36, Dot: "[true false]", Value: "[true false]", Type: "[]bool"},
+				{Kind: TraceIf, Pipeline: "$x", Template: "a", Line: 1, Column: 55, Offset: 54, Dot: "true", Value: "true", Type: "bool"},
+				{Kind: TraceTemplate, Pipeline: "$i", Template: "a", Line: 1, Column: 74, Offset: 73, Dot: "true", Value: "0", Type: "int"},
+				{Kind: TraceAction, Pipeline: ".", Template: "a", Line: 1, Column: 18, Offset: 17, Dot: "0", Value: "0", Type: "int"},
+				{Kind: TraceIf, Pipeline: "$x", Template: "a", Line: 1, Column: 55, Offset: 54, Dot: "false", Value: "false", Type: "bool"},
+				{Kind: TraceAction, Pipeline: "$y := 1", Template: "a", Line: 1, Column: 94, Offset: 93, Dot: "[true false]", Value: "1", Type: "int"},
+			},
+		},
+		{
+			name:   "HTML",
+			engine: EngineHTML,
+			files:  Files{"a": []byte("<p>{{with .}}{{.}}{{end}}</p>")},
+			data:   "<b>",
+			want:   "<p>&lt;b&gt;</p>",
+			events: []TraceEvent{
+				{Kind: TraceWith, Pipeline: ".", Template: "a", Line: 1, Column: 11, Offset: 10, Dot: "<b>", Value: "<b>", Type: "string"},
+				{Kind: TraceAction, Pipeline: ".", Template: "a", Line: 1, Column: 16, Offset: 15, Dot: "<b>", Value: "<b>", Type: "string"},
+			},
+		},
+		{
+			name:  "MissingKey",
+			files: Files{"a": []byte("{{.x}}")},
+			data:  map[string]any{},
+			want:  "<no value>",
+			events: []TraceEvent{
+				{Kind: TraceAction, Pipeline: ".x", Template: "a", Line: 1, Column: 3, Offset: 2, Dot: "map[]", Value: "<nil>", Type: "<nil>"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseFiles(tt.files, Options{Engine: tt.engine, Trace: true}, template.FuncMap{})
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			var trace Trace
+			require.NoError(t, set.ExecuteTrace(context.Background(), &buf, tt.data, Limits{}, &trace))
+			assert.Equal(t, tt.want, buf.String())
+			assert.Equal(t, tt.events, trace.Events)
+			assert.False(t, trace.Truncated)
+		})
+	}
+}
+
+func TestTemplate_ExecuteTrace_untraced(t *testing.T) {
+	set, err := ParseFiles(Files{"a": []byte("{{.}}")}, Options{Trace: true}, template.FuncMap{})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, set.Execute(&buf, 1, Limits{}))
+	assert.Equal(t, "1", buf.String(), "a traced template executes without a trace")
+}
+
+func TestTemplate_ExecuteTrace_bounds(t *testing.T) {
+	set, err := ParseFiles(Files{"a": []byte("{{range .}}{{.}}{{end}}")}, Options{Trace: true}, template.FuncMap{})
+	require.NoError(t, err)
+	var trace Trace
+	require.NoError(t, set.ExecuteTrace(context.Background(), new(bytes.Buffer), make([]int, MaxTraceEvents), Limits{}, &trace))
+	assert.Len(t, trace.Events, MaxTraceEvents)
+	assert.True(t, trace.Truncated)
+
+	long := strings.Repeat("é", MaxTraceValueLen)
+	got := truncate(long)
+	assert.LessOrEqual(t, len(got), MaxTraceValueLen)
+	assert.True(t, strings.HasSuffix(got, "…"))
+	assert.True(t, strings.HasPrefix(long, strings.TrimSuffix(got, "…")))
+}
+
+// countingStringer counts the calls of its String method.
+type countingStringer struct{ calls *int }
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return "s"
+}
+
+func Test_formatValue(t *testing.T) {
+	type point struct {
+		X, y int
+		P    *int
+	}
+	n := 1
+	values := []any{
+		nil,
+		"é",
+		1.5,
+		[]byte("ab"),
+		[]any{nil, true, "x", []int(nil)},
+		map[string]any{"b": 1, "a": map[int]bool{2: true, -1: false}, "c": nil},
+		map[bool]string{true: "t", false: "f"},
+		map[float64]int{2.5: 1, -1: 2},
+		map[[2]int]int{{1, 2}: 3},
+		point{1, 2, &n},
+		&point{X: 1},
+		&[]int{1},
+		&n,
+		(*point)(nil),
+		errors.New("boom"),
+		[]error{errors.New("boom")},
+		map[string]any{},
+	}
+	for _, v := range values {
+		assert.Equal(t, fmt.Sprint(v), formatValue(v), "%#v", v)
+	}
+
+	long := make([]int, 1_000)
+	assert.Equal(t, truncate(fmt.Sprint(long)), formatValue(long))
+
+	calls := 0
+	stringers := make([]countingStringer, 1_000)
+	for i := range stringers {
+		stringers[i].calls = &calls
+	}
+	assert.Equal(t, truncate(fmt.Sprint(stringers)), formatValue(stringers))
+	calls = 0
+	formatValue(stringers)
+	assert.LessOrEqual(t, calls, MaxTraceValueLen/2+1, "formatting stops after the limit")
+}
+
+func TestCache_Parse_trace(t *testing.T) {
+	c := NewCache(0)
+	files := Files{"a": []byte("{{.}}")}
+	_, err := c.Parse(files, Options{}, template.FuncMap{})
+	require.NoError(t, err)
+	_, err = c.Parse(files, Options{Trace: true}, template.FuncMap{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Len(), "traced templates are cached apart")
+}
@@ -0,0 +1,11 @@
+//go:build js && wasm
+// +build js,wasm
+
+package tmpl
+
+import "time"
+
+// yieldInterval is the time after which a cancelable execution yields. The module is
+// single-threaded, so that JavaScript can only abort an execution while it yields to the
+// event loop.
+const yieldInterval = 50 * time.Millisecond
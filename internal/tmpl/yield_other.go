@@ -0,0 +1,8 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package tmpl
+
+// yieldInterval is the time after which a cancelable execution yields. Other goroutines
+// run in parallel on these platforms, so executions never yield.
+const yieldInterval = 0
@@ -6,4 +6,7 @@ type JSValuer interface {
 	Truthy() bool
 	Get(string) JSValuer
 	Int() int
+	String() string
+	Length() int
+	TypeOf() string
 }
@@ -0,0 +1,99 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var _ JSValuer = JSONValue{}
+
+// JSONValue implements the [JSValuer] interface for a value decoded by encoding/json
+// into an interface value, so that options sent as JSON are read exactly like the
+// JavaScript objects passed to the playground.
+type JSONValue struct {
+	V any
+}
+
+// JSONValueError is the panic value of a [JSONValue] method called on a value of the
+// wrong type, as [syscall/js.ValueError] is for JavaScript values.
+type JSONValueError struct {
+	Method string
+	Value  any
+}
+
+func (e *JSONValueError) Error() string {
+	return fmt.Sprintf("json: call of JSONValue.%s on %T", e.Method, e.Value)
+}
+
+// Truthy reports whether the value is truthy in JavaScript.
+func (v JSONValue) Truthy() bool {
+	switch x := v.V.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+// Get returns the property key of an object, or the element at index key of an array.
+// Missing properties are null. It panics if the value is neither.
+func (v JSONValue) Get(key string) JSValuer {
+	switch x := v.V.(type) {
+	case map[string]any:
+		return JSONValue{x[key]}
+	case []any:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(x) {
+			return JSONValue{x[i]}
+		}
+		return JSONValue{}
+	}
+	panic(&JSONValueError{Method: "Get", Value: v.V})
+}
+
+// Int returns the value as an int, truncating any fraction. It panics if the value is
+// not a number.
+func (v JSONValue) Int() int {
+	if x, ok := v.V.(float64); ok {
+		return int(x)
+	}
+	panic(&JSONValueError{Method: "Int", Value: v.V})
+}
+
+// String returns the value as a string. Values of other types are formatted with
+// their default format.
+func (v JSONValue) String() string {
+	if x, ok := v.V.(string); ok {
+		return x
+	}
+	return fmt.Sprint(v.V)
+}
+
+// Length returns the number of elements of an array. It panics if the value is not
+// an array.
+func (v JSONValue) Length() int {
+	if x, ok := v.V.([]any); ok {
+		return len(x)
+	}
+	panic(&JSONValueError{Method: "Length", Value: v.V})
+}
+
+// TypeOf returns the result of the JavaScript typeof operator for the value, except
+// that null is "null", as for [syscall/js.Type].
+func (v JSONValue) TypeOf() string {
+	switch v.V.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	}
+	return "object"
+}
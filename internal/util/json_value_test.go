@@ -0,0 +1,35 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONValue(t *testing.T) {
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(`{"s": "x", "n": 2.5, "z": 0, "e": "", "a": ["l", "r"], "o": {}}`), &v))
+	val := JSONValue{V: v}
+
+	assert.Equal(t, "x", val.Get("s").String())
+	assert.Equal(t, 2, val.Get("n").Int())
+	assert.Equal(t, "r", val.Get("a").Get("1").String())
+	assert.False(t, val.Get("a").Get("2").Truthy())
+	for _, key := range []string{"s", "n", "a", "o"} {
+		assert.True(t, val.Get(key).Truthy(), key)
+	}
+	for _, key := range []string{"z", "e", "missing"} {
+		assert.False(t, val.Get(key).Truthy(), key)
+	}
+
+	assert.Equal(t, 2, val.Get("a").Length())
+	for key, typ := range map[string]string{"s": "string", "n": "number", "a": "object", "o": "object", "missing": "null"} {
+		assert.Equal(t, typ, val.Get(key).TypeOf(), key)
+	}
+
+	assert.PanicsWithError(t, "json: call of JSONValue.Get on <nil>", func() { val.Get("missing").Get("x") })
+	assert.PanicsWithError(t, "json: call of JSONValue.Length on string", func() { val.Get("s").Length() })
+	assert.PanicsWithError(t, "json: call of JSONValue.Int on string", func() { val.Get("s").Int() })
+}
@@ -0,0 +1,99 @@
+package playground
+
+import (
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/engine"
+)
+
+// Format is a data format.
+type Format string
+
+// Supported formats.
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+	FormatXML  Format = "xml"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+)
+
+// EncodeOption configures how data is encoded.
+type EncodeOption func(*encodeConfig)
+
+// encodeConfig holds the settings of an encoding.
+type encodeConfig struct {
+	codec.EncoderOptions
+}
+
+// WithIndent indents encoded data by size spaces, or size tabs if spaces is false, per
+// level. A size of zero selects two spaces or four tabs, and sizes are capped at eight.
+// By default, data is indented by four tabs per level.
+func WithIndent(size int, spaces bool) EncodeOption {
+	return func(o *encodeConfig) {
+		o.IndentSize, o.InsertSpaces, o.NoIndent = size, spaces, false
+	}
+}
+
+// WithoutIndent encodes data without indentation.
+func WithoutIndent() EncodeOption {
+	return func(o *encodeConfig) { o.NoIndent = true }
+}
+
+// WithYAMLStream encodes a top-level array in YAML as a stream of documents separated
+// by "---". [Convert] keeps a YAML stream of several documents a stream without it.
+func WithYAMLStream() EncodeOption {
+	return func(o *encodeConfig) { o.YAMLStream = true }
+}
+
+// Decode decodes data in format into the value that templates are executed with. Maps
+// are decoded as map[string]any and arrays as []any, as are the documents of a YAML
+// stream of several documents. Errors in data are reported as an [*Error].
+func Decode(data []byte, format Format) (any, error) {
+	v, err := engine.DecodeContext(data, codec.Format(format), nil)
+	if err != nil {
+		return nil, newError(err, diagnostic.Sources{Data: data})
+	}
+	if docs, ok := v.(codec.Documents); ok {
+		return []any(docs), nil
+	}
+	return v, nil
+}
+
+// Encode encodes v in format.
+func Encode(v any, format Format, opts ...EncodeOption) ([]byte, error) {
+	out, err := engine.Encode(v, codec.Format(format), encoderOptions(opts))
+	if err != nil {
+		return nil, newError(err, diagnostic.Sources{})
+	}
+	return out, nil
+}
+
+// Convert decodes data in format from and encodes it in format to. YAML and TOML data
+// converted to its own format is reindented, keeping its comments and layout. Errors in
+// data are reported as an [*Error].
+func Convert(data []byte, from, to Format, opts ...EncodeOption) ([]byte, error) {
+	req := &engine.TransformRequest{
+		Data:    data,
+		From:    codec.Format(from),
+		To:      codec.Format(to),
+		Options: encoderOptions(opts),
+	}
+	resp, err := engine.New(1).Transform(req)
+	if err != nil {
+		return nil, newError(err, req.Sources())
+	}
+	return resp.Output, nil
+}
+
+func encoderOptions(opts []EncodeOption) *codec.EncoderOptions {
+	if len(opts) == 0 {
+		return nil
+	}
+	var c encodeConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c.EncoderOptions
+}
@@ -0,0 +1,51 @@
+package playground_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bartventer/go-template-playground/pkg/playground"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	out, err := playground.Convert([]byte("a: [1, 2]\n"), playground.FormatYAML, playground.FormatJSON, playground.WithIndent(2, true))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n", string(out))
+
+	out, err = playground.Convert([]byte(`{"a": 1}`), playground.FormatJSON, playground.FormatJSON, playground.WithoutIndent())
+	require.NoError(t, err)
+	assert.Equal(t, "{\"a\":1}\n", string(out))
+
+	out, err = playground.Convert([]byte(`<a id="1"><b>x</b><b>y</b></a>`), playground.FormatXML, playground.FormatJSON, playground.WithoutIndent())
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":{"@id":"1","b":["x","y"]}}`+"\n", string(out))
+
+	_, err = playground.Convert([]byte("a = "), playground.FormatTOML, playground.FormatJSON)
+	var e *playground.Error
+	require.True(t, errors.As(err, &e))
+	require.Len(t, e.Diagnostics, 1)
+	assert.Equal(t, "decode", e.Diagnostics[0].Stage)
+}
+
+func TestDecodeEncode(t *testing.T) {
+	v, err := playground.Decode([]byte(`{"a": {"b": [true]}}`), playground.FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": map[string]any{"b": []any{true}}}, v)
+
+	out, err := playground.Encode(v, playground.FormatTOML, playground.WithIndent(2, true))
+	require.NoError(t, err)
+	assert.Equal(t, "[a]\n  b = [true]\n", string(out))
+
+	v, err = playground.Decode([]byte("a: 1\n---\nb: 2\n"), playground.FormatYAML)
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"a": 1}, map[string]any{"b": 2}}, v)
+
+	out, err = playground.Encode(v, playground.FormatYAML, playground.WithYAMLStream())
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1\n---\nb: 2\n", string(out))
+
+	_, err = playground.Encode(v, "ini")
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package playground
+
+import (
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+)
+
+// Error is an error in the templates or data given to a function of this package.
+type Error struct {
+	// Diagnostics describes the error, as the playground shows it.
+	Diagnostics []Diagnostic
+	err         error
+}
+
+func newError(err error, sources diagnostic.Sources) *Error {
+	diags := diagnostic.FromError(err, sources)
+	e := &Error{Diagnostics: make([]Diagnostic, len(diags)), err: err}
+	for i, d := range diags {
+		e.Diagnostics[i] = Diagnostic{
+			Stage:    string(d.Stage),
+			Severity: string(d.Severity),
+			Template: d.Template,
+			Line:     d.Line,
+			Column:   d.Column,
+			Offset:   d.Offset,
+			Code:     d.Code,
+			Message:  d.Message,
+		}
+	}
+	return e
+}
+
+func (e *Error) Error() string { return e.err.Error() }
+
+func (e *Error) Unwrap() error { return e.err }
+
+// Diagnostic describes a single problem and where it was found.
+type Diagnostic struct {
+	Stage    string `json:"stage"`              // One of "decode", "parse", "execute" or "encode".
+	Severity string `json:"severity"`           // One of "error" or "warning".
+	Template string `json:"template,omitempty"` // Name of the template source; empty for data.
+	Line     int    `json:"line"`               // 1-based line, or 0 if unknown.
+	Column   int    `json:"column"`             // 1-based column, or 0 if unknown.
+	Offset   int    `json:"offset"`             // 0-based byte offset, or -1 if unknown.
+	Code     string `json:"code,omitempty"`     // Machine-readable error code, such as "ErrStepLimit".
+	Message  string `json:"message"`
+}
@@ -0,0 +1,35 @@
+package playground_test
+
+import (
+	"fmt"
+
+	"github.com/bartventer/go-template-playground/pkg/playground"
+)
+
+func ExampleRenderer_Render() {
+	r := playground.NewRenderer(
+		playground.WithFormat(playground.FormatYAML),
+		playground.WithEntry("index.tmpl"),
+	)
+	out, err := r.Render(playground.Templates{
+		"index.tmpl": []byte(`{{template "_name.tmpl" .}}:{{.tag}}`),
+		"_name.tmpl": []byte(`{{.name | lower}}`),
+	}, []byte("name: App\ntag: v1\n"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(out))
+	// Output: app:v1
+}
+
+func ExampleError() {
+	r := playground.NewRenderer()
+	_, err := r.Render(playground.Templates{"page": []byte("Hello,\n{{.name}")}, []byte(`{}`))
+	if e, ok := err.(*playground.Error); ok {
+		for _, d := range e.Diagnostics {
+			fmt.Printf("%s:%d: %s error: %s\n", d.Template, d.Line, d.Stage, d.Message)
+		}
+	}
+	// Output: page:2: parse error: bad character U+007D '}'
+}
@@ -0,0 +1,118 @@
+package playground
+
+import (
+	"time"
+
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// Engine selects the template package that templates are executed with.
+type Engine string
+
+// Supported engines.
+const (
+	EngineText Engine = "text" // text/template.
+	EngineHTML Engine = "html" // html/template, which escapes output for HTML.
+)
+
+// MissingKey controls the behavior when a map is indexed with a key it does not hold.
+type MissingKey string
+
+// Supported behaviors, as for the "missingkey" option of text/template.
+const (
+	MissingKeyDefault MissingKey = "default" // Print "<no value>".
+	MissingKeyZero    MissingKey = "zero"    // Print the zero value.
+	MissingKeyError   MissingKey = "error"   // Stop execution with an error.
+)
+
+// Limits bounds the resources used to execute a template. A zero field selects its
+// default, and a negative field disables the limit.
+type Limits struct {
+	Timeout        time.Duration // Wall-clock deadline; defaults to 5s.
+	MaxOutputBytes int           // Maximum output size; defaults to 8 MiB.
+	MaxSteps       int           // Maximum number of range iterations and template calls; defaults to 1000000.
+	MaxDepth       int           // Maximum nesting depth of template calls; defaults to 256.
+}
+
+// config holds the settings of a [Renderer].
+type config struct {
+	format    Format
+	options   tmpl.Options
+	funcs     map[string]any
+	cacheSize int
+}
+
+// Option configures a [Renderer].
+type Option func(*config)
+
+// WithFormat sets the format of the data given to [Renderer.Render].
+func WithFormat(format Format) Option {
+	return func(c *config) { c.format = format }
+}
+
+// WithEngine sets the template engine.
+func WithEngine(engine Engine) Option {
+	return func(c *config) { c.options.Engine = tmpl.Engine(engine) }
+}
+
+// WithEntry sets the name of the template to execute.
+func WithEntry(name string) Option {
+	return func(c *config) { c.options.Entry = name }
+}
+
+// WithDelims sets the action delimiters, which default to "{{" and "}}".
+func WithDelims(left, right string) Option {
+	return func(c *config) { c.options.LeftDelim, c.options.RightDelim = left, right }
+}
+
+// WithMissingKey sets the behavior when a map has no entry for a key.
+func WithMissingKey(missingKey MissingKey) Option {
+	return func(c *config) { c.options.MissingKey = tmpl.MissingKey(missingKey) }
+}
+
+// WithLimits sets the execution limits.
+func WithLimits(limits Limits) Option {
+	return func(c *config) { c.options.Limits = tmpl.Limits(limits) }
+}
+
+// WithClock fixes the instant returned by the now and date functions, in the time zone
+// loc. A nil loc selects local time.
+func WithClock(now time.Time, loc *time.Location) Option {
+	return func(c *config) {
+		d := c.determinism()
+		d.Now, d.Location = now, loc
+	}
+}
+
+// WithSeed seeds the random source of the randInt function, so that its results are
+// reproducible.
+func WithSeed(seed uint64) Option {
+	return func(c *config) { c.determinism().Seed = seed }
+}
+
+// WithFuncs adds funcs to the functions available to templates. They take precedence
+// over the playground functions of the same name.
+func WithFuncs(funcs map[string]any) Option {
+	return func(c *config) {
+		if c.funcs == nil {
+			c.funcs = make(map[string]any, len(funcs))
+		}
+		for name, fn := range funcs {
+			c.funcs[name] = fn
+		}
+	}
+}
+
+// WithCacheSize sets the number of parsed template sets the renderer caches. It
+// defaults to 32.
+func WithCacheSize(size int) Option {
+	return func(c *config) { c.cacheSize = size }
+}
+
+// determinism returns the deterministic settings of c, creating them if needed.
+func (c *config) determinism() *tmpl.Determinism {
+	if c.options.Deterministic == nil {
+		c.options.Deterministic = new(tmpl.Determinism)
+	}
+	return c.options.Deterministic
+}
@@ -0,0 +1,100 @@
+// Package playground renders Go templates and converts data exactly as the Go Template
+// Playground does, so that Go services can rely on the behavior prototyped there: the
+// same function map, the same data codecs, and the same limits and diagnostics.
+//
+// A [Renderer] holds the options of a rendering and a cache of parsed templates:
+//
+//	r := playground.NewRenderer(
+//		playground.WithFormat(playground.FormatYAML),
+//		playground.WithEntry("index.tmpl"),
+//	)
+//	out, err := r.Render(templates, values)
+//
+// Data can also be decoded, encoded and converted between formats with [Decode],
+// [Encode] and [Convert].
+//
+// # Compatibility
+//
+// This package follows semantic versioning. Once released in a v1 version of the
+// module, its API changes only in backward compatible ways until the next major
+// version: existing identifiers keep their meaning, and new ones, such as formats,
+// options and functions, may be added. The output of the template functions is part of
+// this guarantee, except for bug fixes. The internal packages of the module carry no
+// such guarantee and must not be relied upon.
+package playground
+
+import (
+	"github.com/bartventer/go-template-playground/internal/codec"
+	"github.com/bartventer/go-template-playground/internal/diagnostic"
+	"github.com/bartventer/go-template-playground/internal/engine"
+	"github.com/bartventer/go-template-playground/internal/tmpl"
+)
+
+// Templates holds template sources, keyed by name.
+type Templates map[string][]byte
+
+// Funcs returns a copy of the functions available to templates in the playground. It
+// may be passed to the Funcs method of text/template and html/template templates.
+func Funcs() map[string]any {
+	funcs := tmpl.TemplateFuncs()
+	m := make(map[string]any, len(funcs))
+	for name, fn := range funcs {
+		m[name] = fn
+	}
+	return m
+}
+
+// Renderer executes templates with the playground functions. It caches parsed
+// templates, so that rendering the same templates with changing data skips parsing,
+// and is safe for concurrent use.
+type Renderer struct {
+	engine  *engine.Engine
+	format  codec.Format
+	options tmpl.Options
+}
+
+// NewRenderer returns a renderer configured by opts. By default, data is decoded as
+// JSON and templates are executed with text/template.
+func NewRenderer(opts ...Option) *Renderer {
+	c := config{format: FormatJSON}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	var engineOpts []engine.Option
+	if c.funcs != nil {
+		engineOpts = append(engineOpts, engine.WithFuncs(c.funcs))
+	}
+	return &Renderer{
+		engine:  engine.New(c.cacheSize, engineOpts...),
+		format:  codec.Format(c.format),
+		options: c.options,
+	}
+}
+
+// Render decodes data in the format of the renderer and executes the entry template
+// with it. If templates holds a single template, it is the entry template unless
+// another one is set with [WithEntry]. Errors in the sources are reported as an
+// [*Error].
+func (r *Renderer) Render(templates Templates, data []byte) ([]byte, error) {
+	req := &engine.RenderRequest{
+		Templates: tmpl.Files(templates),
+		Data:      data,
+		Format:    r.format,
+		Options:   r.options,
+	}
+	resp, err := r.engine.Render(req)
+	if err != nil {
+		return nil, newError(err, req.Sources())
+	}
+	return resp.Output, nil
+}
+
+// Execute executes the entry template with data, which need not have been decoded by
+// [Decode]. It is otherwise like [Renderer.Render].
+func (r *Renderer) Execute(templates Templates, data any) ([]byte, error) {
+	out, err := r.engine.Execute(tmpl.Files(templates), data, r.options)
+	if err != nil {
+		return nil, newError(err, diagnostic.Sources{Templates: tmpl.Files(templates)})
+	}
+	return out, nil
+}
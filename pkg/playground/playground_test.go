@@ -0,0 +1,105 @@
+package playground_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/bartventer/go-template-playground/pkg/playground"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	templates := playground.Templates{
+		"index.tmpl":  []byte(`<<template "_b.tmpl" .>> <<.image.tag | upper>>`),
+		"_b.tmpl":     []byte(`<<shout .name>>`),
+		"unused.tmpl": []byte(`<<.nope>>`),
+	}
+	r := playground.NewRenderer(
+		playground.WithFormat(playground.FormatYAML),
+		playground.WithEntry("index.tmpl"),
+		playground.WithDelims("<<", ">>"),
+		playground.WithFuncs(map[string]any{"shout": func(s string) string { return s + "!" }}),
+	)
+	out, err := r.Render(templates, []byte("name: app\nimage:\n  tag: v1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "app! V1", string(out))
+}
+
+func TestRenderer_Execute(t *testing.T) {
+	r := playground.NewRenderer(
+		playground.WithEngine(playground.EngineHTML),
+		playground.WithClock(time.Date(2024, 6, 30, 22, 0, 0, 0, time.UTC), time.FixedZone("X", 3600*3)),
+		playground.WithSeed(1),
+	)
+	templates := playground.Templates{"a": []byte(`<p>{{.}}</p> {{date}} {{randInt 1000}}`)}
+	first, err := r.Execute(templates, "<b>")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(first), "<p>&lt;b&gt;</p> 2024-07-01 "), string(first))
+	second, err := r.Execute(templates, "<b>")
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second), "output is reproducible")
+}
+
+func TestRenderer_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        *playground.Renderer
+		template string
+		data     string
+		want     playground.Diagnostic
+	}{
+		{
+			name:     "Decode",
+			r:        playground.NewRenderer(),
+			template: `{{.}}`,
+			data:     "{\n  \"a\": 1,\n}",
+			want: playground.Diagnostic{
+				Stage: "decode", Severity: "error", Line: 3, Column: 1, Offset: 12,
+				Message: "invalid character '}' looking for beginning of object key string",
+			},
+		},
+		{
+			name:     "MissingKey",
+			r:        playground.NewRenderer(playground.WithMissingKey(playground.MissingKeyError)),
+			template: "\n{{.a}}",
+			data:     `{}`,
+			want: playground.Diagnostic{
+				Stage: "execute", Severity: "error", Template: "page", Line: 2, Column: 3, Offset: 3,
+				Message: `executing "page" at <.a>: map has no entry for key "a"`,
+			},
+		},
+		{
+			name:     "Limit",
+			r:        playground.NewRenderer(playground.WithLimits(playground.Limits{MaxSteps: 5})),
+			template: `{{range 10}}{{end}}`,
+			data:     `{}`,
+			want: playground.Diagnostic{
+				Stage: "execute", Severity: "error", Template: "page", Line: 1, Column: 9, Offset: 8,
+				Code: "ErrStepLimit", Message: "limit exceeded: execution took more than 5 steps",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.r.Render(playground.Templates{"page": []byte(tt.template)}, []byte(tt.data))
+			var e *playground.Error
+			require.True(t, errors.As(err, &e), "%v", err)
+			assert.Equal(t, []playground.Diagnostic{tt.want}, e.Diagnostics)
+		})
+	}
+}
+
+func TestFuncs(t *testing.T) {
+	funcs := playground.Funcs()
+	delete(funcs, "upper")
+	tpl, err := template.New("a").Funcs(playground.Funcs()).Parse(`{{upper "x"}}`)
+	require.NoError(t, err, "the returned map is a copy")
+
+	var buf strings.Builder
+	require.NoError(t, tpl.Execute(&buf, nil))
+	assert.Equal(t, "X", buf.String())
+}
@@ -0,0 +1,12 @@
+// Runs the WASI build of the playground with Node's built-in WASI support, passing
+// standard input and output through.
+//
+// Usage: node scripts/wasi.mjs path/to/playground-wasi.wasm < request.json
+import { readFile } from "node:fs/promises";
+import { argv, exit } from "node:process";
+import { WASI } from "node:wasi";
+
+const wasi = new WASI({ version: "preview1", args: ["wasi"], returnOnExit: true });
+const module = await WebAssembly.compile(await readFile(argv[2]));
+const instance = await WebAssembly.instantiate(module, wasi.getImportObject());
+exit(wasi.start(instance));
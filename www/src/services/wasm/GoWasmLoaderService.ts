@@ -10,6 +10,7 @@ export class GoWasmLoaderService {
 		this.#go = new Go();
 	}
 
+	/** init loads and runs the module, and resolves once the module reports that it is ready. */
 	async init(): Promise<void> {
 		const response = await fetch(this.#wasmUrl, {
 			headers: {
@@ -28,8 +29,12 @@ export class GoWasmLoaderService {
 			this.#go.importObject,
 		);
 
+		const ready = new Promise<void>((resolve) => {
+			globalThis.onReady = () => resolve();
+		});
+		let exited: Promise<void>;
 		try {
-			this.#go.run(result.instance);
+			exited = this.#go.run(result.instance);
 		} catch (error) {
 			try {
 				this.#go.exit(1);
@@ -44,5 +49,11 @@ export class GoWasmLoaderService {
 			}
 			throw error;
 		}
+		await Promise.race([
+			ready,
+			exited.then(() => {
+				throw new Error("Go WebAssembly module exited before it was ready");
+			}),
+		]);
 	}
 }
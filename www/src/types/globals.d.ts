@@ -12,6 +12,16 @@ import type {
 import "./editor";
 
 declare global {
+	/**
+	 * onReady is called by the WebAssembly module once its functions are set.
+	 */
+	var onReady: ((namespace: typeof globalThis) => void) | undefined;
+
+	/**
+	 * shutdown removes and releases the functions of the WebAssembly module, and lets it exit.
+	 */
+	function shutdown(): void;
+
 	/**
 	 * processTemplate is the function that processes a template with a context.
 	 * @param templateView - The byte array containing the template data.